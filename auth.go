@@ -0,0 +1,84 @@
+package main
+
+import (
+	"net/http"
+	"os"
+
+	// Packages
+	client "github.com/mutablelogic/go-client"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// keyAuth describes how the API key is attached to every request.
+type keyAuth struct {
+	header string // request header carrying the key
+	scheme string // scheme preceding the key in the header, or empty for the key alone
+}
+
+// keyAuthTransport sets the API key header on each request before passing
+// it to the next transport.
+type keyAuthTransport struct {
+	value  string
+	header string
+	base   http.RoundTripper
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// defaultAuthHeader is the request header which carries the API key, unless
+// another is set.
+const defaultAuthHeader = "Authorization"
+
+// defaultKeyAuth sends the API key as a bearer token.
+var defaultKeyAuth = keyAuth{header: defaultAuthHeader, scheme: client.Bearer}
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// resolveKeyAuth returns how the API key is attached from the
+// KAIAK_AUTH_HEADER and KAIAK_AUTH_SCHEME environment variables, or else the
+// defaults. The scheme may be set empty, to send the key alone.
+func resolveKeyAuth() keyAuth {
+	auth := defaultKeyAuth
+	if v := os.Getenv("KAIAK_AUTH_HEADER"); v != "" {
+		auth.header = v
+	}
+	if v, ok := os.LookupEnv("KAIAK_AUTH_SCHEME"); ok {
+		auth.scheme = v
+	}
+	return auth
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// RoundTrip sends a copy of the request with the API key header, leaving the
+// original unmodified as RoundTripper requires.
+func (t *keyAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	r.Header.Set(t.header, t.value)
+	return t.base.RoundTrip(r)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// value returns the header value for the API key.
+func (a keyAuth) value(apiKey string) string {
+	if a.scheme == "" {
+		return apiKey
+	}
+	return a.scheme + " " + apiKey
+}
+
+// optKeyAuth sets the header described by auth to the API key on every
+// request.
+func optKeyAuth(auth keyAuth, apiKey string) client.ClientOpt {
+	return func(c *client.Client) error {
+		c.Transport = &keyAuthTransport{value: auth.value(apiKey), header: auth.header, base: c.Transport}
+		return nil
+	}
+}
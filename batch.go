@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	// Packages
+	httpclient "github.com/mutablelogic/go-server/pkg/provider/httpclient"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// readBatcher coalesces the reads of instances made within a short window,
+// such as by the resources of a large refresh, into one request to the
+// server's batch endpoint. When the server has no batch endpoint, instances
+// are read individually and no further reads are batched.
+type readBatcher struct {
+	client      *httpclient.Client
+	window      time.Duration
+	unsupported atomic.Bool
+
+	mu      sync.Mutex
+	pending *readBatch // reads waiting to be sent, or nil
+}
+
+// readBatch holds the reads waiting for one batch request, as instance
+// name → a channel for each waiting read.
+type readBatch struct {
+	waiters map[string][]chan readResult
+}
+
+// readResult is the result of reading an instance.
+type readResult struct {
+	response *getResourceInstanceResponse
+	err      error
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// batchReadWindow is how long a read waits for others to join its batch
+	batchReadWindow = 10 * time.Millisecond
+
+	// maxBatchReads is the most instances read in one batch request
+	maxBatchReads = 100
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// newReadBatcher returns a batcher of reads made with the given client.
+func newReadBatcher(cl *httpclient.Client) *readBatcher {
+	return &readBatcher{client: cl, window: batchReadWindow}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// get reads the named instance, as part of a batch when the server supports
+// batch reads. The batch request is not canceled with ctx, as other reads
+// share it, but get returns as soon as ctx is canceled.
+func (b *readBatcher) get(ctx context.Context, name string) (*getResourceInstanceResponse, error) {
+	if b.unsupported.Load() {
+		return getResourceInstance(ctx, b.client, name)
+	}
+
+	// Join the pending batch, or start a new one which is sent after the window
+	result := make(chan readResult, 1)
+	b.mu.Lock()
+	batch := b.pending
+	if batch == nil {
+		batch = &readBatch{waiters: map[string][]chan readResult{}}
+		b.pending = batch
+		time.AfterFunc(b.window, func() {
+			if b.take(batch) {
+				b.send(context.WithoutCancel(ctx), batch)
+			}
+		})
+	}
+	batch.waiters[name] = append(batch.waiters[name], result)
+	full := len(batch.waiters) >= maxBatchReads
+	b.mu.Unlock()
+
+	// Send a full batch without waiting for the window
+	if full && b.take(batch) {
+		go b.send(context.WithoutCancel(ctx), batch)
+	}
+
+	select {
+	case r := <-result:
+		return r.response, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// take removes the batch from pending, returning false if it was already
+// taken to be sent.
+func (b *readBatcher) take(batch *readBatch) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.pending != batch {
+		return false
+	}
+	b.pending = nil
+	return true
+}
+
+// send reads the instances of a batch and passes each result to its waiting
+// reads. Instances missing from the batch response are read individually, so
+// that a missing instance is reported as not found in the usual way.
+func (b *readBatcher) send(ctx context.Context, batch *readBatch) {
+	names := slices.Sorted(maps.Keys(batch.waiters))
+	var found map[string]*getResourceInstanceResponse
+	if len(names) > 1 && !b.unsupported.Load() {
+		response, err := getResourceInstances(ctx, b.client, names)
+		switch status := httpStatus(err); {
+		case err == nil:
+			found = make(map[string]*getResourceInstanceResponse, len(response.Instances))
+			for i := range response.Instances {
+				found[response.Instances[i].Instance.Name] = &response.Instances[i]
+			}
+		case status == http.StatusNotFound || status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented:
+			b.unsupported.Store(true)
+			logInfo(ctx, "Server does not support batch reads: reading instances individually", map[string]interface{}{
+				"status": status,
+			})
+		default:
+			for _, name := range names {
+				b.deliver(batch, name, readResult{err: err})
+			}
+			return
+		}
+	}
+
+	// Read any instances not in the batch response individually
+	var wg sync.WaitGroup
+	for _, name := range names {
+		if response, ok := found[name]; ok {
+			b.deliver(batch, name, readResult{response: response})
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := getResourceInstance(ctx, b.client, name)
+			b.deliver(batch, name, readResult{response: response, err: err})
+		}()
+	}
+	wg.Wait()
+}
+
+// deliver passes the result of reading an instance to each read waiting for
+// it.
+func (b *readBatcher) deliver(batch *readBatch, name string, result readResult) {
+	for _, waiter := range batch.waiters[name] {
+		waiter <- result
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"sync"
+
+	// Packages
+	client "github.com/mutablelogic/go-client"
+	clientcredentials "golang.org/x/oauth2/clientcredentials"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// discoveryCache holds the resource types discovered from each server, so
+// that provider instances in the same process, such as aliased provider
+// blocks, and repeated calls to Resources do not discover them again. Entries
+// are keyed by endpoint and credentials, so that resource types discovered
+// with one set of credentials are never returned for another.
+type discoveryCache struct {
+	sync.Mutex
+	entries map[string][]resourceMeta // endpoint and credentials digest → resource types
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// discoveries is the process-wide cache of discovered resource types.
+var discoveries = &discoveryCache{entries: map[string][]resourceMeta{}}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// discover returns the resource types discovered from the server at the
+// given endpoint with the given credentials, from the cache when they have
+// already been discovered. Only a discovery which succeeds is cached, so
+// that one which fails is tried again.
+func (c *discoveryCache) discover(ctx context.Context, endpoint, credentials string, opts []client.ClientOpt, workers int) ([]resourceMeta, error) {
+	key := endpoint + "\x00" + credentials
+	c.Lock()
+	metas, ok := c.entries[key]
+	c.Unlock()
+	if ok {
+		logDebug(ctx, "Using cached resource types", map[string]interface{}{
+			"endpoint":  endpoint,
+			"resources": len(metas),
+		})
+		return slices.Clone(metas), nil
+	}
+
+	metas, err := discoverEndpoint(ctx, endpoint, opts, workers)
+	if err != nil {
+		return metas, err
+	}
+	c.Lock()
+	c.entries[key] = slices.Clone(metas)
+	c.Unlock()
+	return metas, nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// credentialsDigest returns a digest identifying the credentials used to
+// discover resource types, so that they can key the cache without it holding
+// the credentials themselves.
+func credentialsDigest(apiKey string, auth keyAuth, oauth *clientcredentials.Config, signer *requestSigner) string {
+	v := struct {
+		ApiKey           string   `json:"api_key"`
+		AuthHeader       string   `json:"auth_header"`
+		AuthScheme       string   `json:"auth_scheme"`
+		OAuthTokenURL    string   `json:"oauth_token_url,omitempty"`
+		OAuthClientID    string   `json:"oauth_client_id,omitempty"`
+		OAuthSecret      string   `json:"oauth_client_secret,omitempty"`
+		OAuthScopes      []string `json:"oauth_scopes,omitempty"`
+		SigningKey       []byte   `json:"signing_key,omitempty"`
+		SigningAlgorithm string   `json:"signing_algorithm,omitempty"`
+	}{
+		ApiKey:     apiKey,
+		AuthHeader: auth.header,
+		AuthScheme: auth.scheme,
+	}
+	if oauth != nil {
+		v.OAuthTokenURL = oauth.TokenURL
+		v.OAuthClientID = oauth.ClientID
+		v.OAuthSecret = oauth.ClientSecret
+		v.OAuthScopes = oauth.Scopes
+	}
+	if signer != nil {
+		v.SigningKey = signer.key
+		v.SigningAlgorithm = signer.algorithm
+	}
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
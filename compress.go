@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"sync/atomic"
+
+	// Packages
+	client "github.com/mutablelogic/go-client"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// compressingTransport gzips request bodies of at least minSize bytes
+// before passing them to the next transport. When the server rejects a
+// compressed body as an unsupported media type, the request is repeated
+// uncompressed and no further requests are compressed.
+type compressingTransport struct {
+	minSize     int
+	base        http.RoundTripper
+	unsupported atomic.Bool
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// compressMinSize is the smallest request body which is compressed, below
+// which the overhead outweighs the saving.
+const compressMinSize = 1024
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// RoundTrip sends a copy of the request with a gzipped body, leaving the
+// original unmodified as RoundTripper requires.
+func (t *compressingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.unsupported.Load() || req.Body == nil || req.Body == http.NoBody || req.Header.Get("Content-Encoding") != "" {
+		return t.base.RoundTrip(req)
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if len(body) < t.minSize {
+		return t.base.RoundTrip(withBody(req, body))
+	}
+
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	if _, err := zw.Write(body); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	gzipped := withBody(req, compressed.Bytes())
+	gzipped.Header.Set("Content-Encoding", "gzip")
+	response, err := t.base.RoundTrip(gzipped)
+	if err != nil || response.StatusCode != http.StatusUnsupportedMediaType {
+		return response, err
+	}
+
+	// The server does not accept compressed bodies
+	response.Body.Close()
+	t.unsupported.Store(true)
+	logWarn(req.Context(), "Server does not accept compressed request bodies: sending uncompressed", map[string]interface{}{
+		"host": req.URL.Host,
+	})
+	return t.base.RoundTrip(withBody(req, body))
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// withBody returns a copy of the request with the given body.
+func withBody(req *http.Request, body []byte) *http.Request {
+	r := req.Clone(req.Context())
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	r.ContentLength = int64(len(body))
+	return r
+}
+
+// optCompression gzips request bodies of at least minSize bytes.
+func optCompression(minSize int) client.ClientOpt {
+	return func(c *client.Client) error {
+		c.Transport = &compressingTransport{minSize: minSize, base: c.Transport}
+		return nil
+	}
+}
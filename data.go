@@ -3,6 +3,8 @@ package main
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 
 	// Packages
 	datasource "github.com/hashicorp/terraform-plugin-framework/datasource"
@@ -17,7 +19,7 @@ import (
 
 // resourcesDataSource implements the kaiak_resources data source.
 type resourcesDataSource struct {
-	client *httpclient.Client
+	data *providerData
 }
 
 // resourcesDataSourceModel maps the data source schema to Go types.
@@ -131,17 +133,17 @@ func (d *resourcesDataSource) Configure(_ context.Context, req datasource.Config
 	if req.ProviderData == nil {
 		return
 	}
-	client, ok := req.ProviderData.(*httpclient.Client)
+	data, ok := req.ProviderData.(*providerData)
 	if !ok {
 		resp.Diagnostics.AddError("Unexpected provider data type",
-			fmt.Sprintf("Expected *httpclient.Client, got %T", req.ProviderData))
+			fmt.Sprintf("Expected *providerData, got %T", req.ProviderData))
 		return
 	}
-	d.client = client
+	d.data = data
 }
 
 func (d *resourcesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
-	if d.client == nil {
+	if d.data == nil {
 		resp.Diagnostics.AddError("Data source not configured",
 			"The provider has not been configured. Ensure the provider block is present and valid.")
 		return
@@ -166,18 +168,38 @@ func (d *resourcesDataSource) Read(ctx context.Context, req datasource.ReadReque
 		listReq.Type = &t
 	}
 
-	result, err := d.client.ListResources(ctx, listReq)
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to list resources", err.Error())
-		return
+	// List the resource types on each endpoint, keeping only those it serves.
+	// The provider name and version are those of the default endpoint
+	clients := []*httpclient.Client{d.data.client}
+	for _, cl := range d.data.overrides {
+		if !slices.Contains(clients, cl) {
+			clients = append(clients, cl)
+		}
 	}
+	var resources []schema.ResourceMeta
+	for _, cl := range clients {
+		result, err := cl.ListResources(ctx, listReq)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to list resources", err.Error())
+			return
+		}
+		if cl == d.data.client {
+			config.ProviderName = types.StringValue(result.Provider)
+			config.Version = types.StringValue(result.Version)
+		}
+		for _, r := range result.Resources {
+			if d.data.clientFor(r.Name) == cl {
+				resources = append(resources, r)
+			}
+		}
+	}
+	slices.SortStableFunc(resources, func(a, b schema.ResourceMeta) int {
+		return strings.Compare(a.Name, b.Name)
+	})
 
 	// Map the response into the model
-	config.ProviderName = types.StringValue(result.Provider)
-	config.Version = types.StringValue(result.Version)
-	config.Resources = make([]resourceDataSourceModel, 0, len(result.Resources))
-
-	for _, r := range result.Resources {
+	config.Resources = make([]resourceDataSourceModel, 0, len(resources))
+	for _, r := range resources {
 		res := resourceDataSourceModel{
 			Name:       types.StringValue(r.Name),
 			Attributes: make([]attributeDataSourceModel, 0, len(r.Attributes)),
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	// Packages
+	tfprotov6 "github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	tftypes "github.com/hashicorp/terraform-plugin-go/tftypes"
+	schema "github.com/mutablelogic/go-server/pkg/provider/schema"
+)
+
+func TestResourcesEndpoints(t *testing.T) {
+	// The default server also lists b, and the override also lists c, but
+	// each type is listed only from the server it is routed to
+	primary := newTestServer(t, resourceMeta{Name: "a"}, resourceMeta{Name: "b"})
+	edge := newTestServer(t,
+		resourceMeta{Name: "b", Instances: []schema.InstanceMeta{{Name: "b.edge", Resource: "b"}}},
+		resourceMeta{Name: "c"})
+	p := newTestProvider(t, primary, map[string]tftypes.Value{
+		"endpoints": tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, map[string]tftypes.Value{
+			"b": stringValue(edge.URL),
+		}),
+	})
+
+	ctx := context.Background()
+	schemas, err := p.server.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	typ := schemas.DataSourceSchemas["kaiak_resources"].ValueType().(tftypes.Object)
+	resp, err := p.server.ReadDataSource(ctx, &tfprotov6.ReadDataSourceRequest{
+		TypeName: "kaiak_resources",
+		Config:   dynamicValue(t, objectValue(t, typ, nil)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDiagnostics(t, "ReadDataSource", resp.Diagnostics)
+	state, err := resp.State.Unmarshal(typ)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var resources []tftypes.Value
+	if err := attrValue(t, state, "resources").As(&resources); err != nil {
+		t.Fatal(err)
+	}
+	var names, instances []string
+	for _, r := range resources {
+		var name string
+		var list []tftypes.Value
+		attrValue(t, r, "name").As(&name)
+		attrValue(t, r, "instances").As(&list)
+		names = append(names, name)
+		for _, instance := range list {
+			var name string
+			attrValue(t, instance, "name").As(&name)
+			instances = append(instances, name)
+		}
+	}
+	if want := []string{"a", "b"}; !slices.Equal(names, want) {
+		t.Errorf("resource types %q, want %q", names, want)
+	}
+	if want := []string{"b.edge"}; !slices.Equal(instances, want) {
+		t.Errorf("instances %q, want %q", instances, want)
+	}
+}
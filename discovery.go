@@ -0,0 +1,450 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	// Packages
+	client "github.com/mutablelogic/go-client"
+	httpresponse "github.com/mutablelogic/go-server/pkg/httpresponse"
+	httpclient "github.com/mutablelogic/go-server/pkg/provider/httpclient"
+	schema "github.com/mutablelogic/go-server/pkg/provider/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// listResourcesResponse mirrors schema.ListResourcesResponse, but decodes
+// resource attributes with any extended metadata the server provides.
+type listResourcesResponse struct {
+	Provider  string         `json:"provider"`
+	Version   string         `json:"version,omitempty"`
+	Resources []resourceMeta `json:"resources"`
+}
+
+// resourceMeta mirrors schema.ResourceMeta with extended attribute metadata.
+type resourceMeta struct {
+	Name       string                `json:"name"`
+	Attributes []attributeMeta       `json:"attributes"`
+	Instances  []schema.InstanceMeta `json:"instances"`
+	Available  *bool                 `json:"available,omitempty"` // nil when the server does not report availability
+
+	// Description of the resource type, replacing the generated one
+	Description string `json:"description,omitempty"`
+
+	// Template for the computed url attribute, with attribute names in
+	// braces, e.g. "http://localhost{listen}{path}"
+	URLTemplate string `json:"url_template,omitempty"`
+
+	// How the schema is built, from the provider settings rather than the
+	// server: the separator of the blocks and field in attribute names, or
+	// a dot when empty, whether an attribute of unknown type fails the
+	// schema, and whether each block captures members missing from it
+	Separator   string `json:"-"`
+	StrictTypes bool   `json:"-"`
+	BlockExtras bool   `json:"-"`
+}
+
+// createResourceInstanceRequest extends schema.CreateResourceInstanceRequest
+// with attributes which are applied as the instance is created, for servers
+// which support staged attributes.
+type createResourceInstanceRequest struct {
+	schema.CreateResourceInstanceRequest
+	Attributes schema.State `json:"attributes,omitempty"`
+	Apply      bool         `json:"apply,omitempty"`
+}
+
+// getResourceInstanceResponse extends schema.GetResourceInstanceResponse
+// with the runtime status of the instance (e.g. "running"), advisories
+// about it, for example that it uses a deprecated feature, a version which
+// changes with every modification, and a stable identifier which survives
+// renames, for servers which report them.
+type getResourceInstanceResponse struct {
+	schema.GetResourceInstanceResponse
+	Status   string   `json:"status,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+	Version  string   `json:"version,omitempty"`
+	UUID     string   `json:"uuid,omitempty"`
+}
+
+// renameResourceInstanceRequest gives the new name of an instance, for
+// servers which support renaming instances.
+type renameResourceInstanceRequest struct {
+	Name string `json:"name"`
+}
+
+// batchGetRequest names the instances to read in one request, for servers
+// which support batch reads.
+type batchGetRequest struct {
+	Names []string `json:"names"`
+}
+
+// batchGetResponse holds the instances read in one request. Instances which
+// could not be read are omitted.
+type batchGetResponse struct {
+	Instances []getResourceInstanceResponse `json:"instances"`
+}
+
+// idempotencyKeyHeader is the request header carrying the idempotency key
+// of a create request.
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// ifMatchHeader is the request header carrying the version of an instance
+// which an update or destroy expects to modify.
+const ifMatchHeader = "If-Match"
+
+// attributeMeta is a kaiak attribute together with optional extended
+// metadata. Servers which do not provide the extended fields leave them
+// empty, in which case the provider behaves as for a plain attribute.
+type attributeMeta struct {
+	schema.Attribute
+
+	// Names of attributes which must be set together with this one
+	RequiredWith []string `json:"required_with,omitempty"`
+
+	// Names of attributes which cannot be set together with this one
+	ConflictsWith []string `json:"conflicts_with,omitempty"`
+
+	// Names of normalizers applied before comparing values (e.g. "lower")
+	Normalize []string `json:"normalize,omitempty"`
+
+	// Computed by the server from other attributes (e.g. a URL from the
+	// host and port), so it is read-only even if not marked as such
+	Derived bool `json:"derived,omitempty"`
+
+	// Sent to the server but never stored in state, for a secret such as a
+	// password which is only submitted (requires Terraform 1.11 or later)
+	WriteOnly bool `json:"write_only,omitempty"`
+
+	// Names of transforms applied to a string value sent to the server
+	// (e.g. "base64encode"), and reversed where possible on read
+	Transform []string `json:"transform,omitempty"`
+
+	// Cleared on the server when removed from configuration, rather than
+	// keeping its current value
+	Clearable bool `json:"clearable,omitempty"`
+
+	// An empty string is the same as no value, so it is sent as null and
+	// kept in state as configured when the server reports no value
+	EmptyAsNull bool `json:"empty_as_null,omitempty"`
+
+	// The order of elements of a list is not meaningful
+	Unordered bool `json:"unordered,omitempty"`
+
+	// Minimum and maximum number of elements of a list or map
+	MinItems *int `json:"min_items,omitempty"`
+	MaxItems *int `json:"max_items,omitempty"`
+
+	// The elements of a list must be unique, for a list which the server
+	// treats as a set
+	UniqueItems bool `json:"unique_items,omitempty"`
+
+	// Regular expression which a string, or each string element of a list
+	// or map, must match
+	Pattern string `json:"pattern,omitempty"`
+
+	// Condition under which a change requires replacing the instance, one
+	// of "always", "decrease", "increase" or "remove"
+	ReplaceIf string `json:"replace_if,omitempty"`
+
+	// Allowed values of a string, or of each string element of a list or map
+	Enum []string `json:"enum,omitempty"`
+
+	// Former names of the attribute, accepted in configuration with a
+	// deprecation warning
+	Aliases []string `json:"aliases,omitempty"`
+
+	// Identifies the instance together with its label (e.g. a namespace),
+	// and follows the label in an import ID
+	Address bool `json:"address,omitempty"`
+
+	// Position in which the attribute is applied, for an attribute which
+	// depends on others being set first (e.g. a mode-specific field after
+	// the mode). When any attribute has a non-zero position, attributes are
+	// applied in ascending order with a request for each position
+	ApplyOrder int `json:"apply_order,omitempty"`
+
+	// Deprecation message, shown when the attribute is set in configuration
+	Deprecated string `json:"deprecated,omitempty"`
+
+	// Description in Markdown, for documentation and editor tooltips
+	MarkdownDescription string `json:"markdown_description,omitempty"`
+
+	// Block into which a top-level attribute is grouped by the prefix of
+	// its name, from attribute_groups rather than the server
+	Group string `json:"-"`
+
+	// Separator of the blocks and field in the name, from
+	// attribute_separator rather than the server, or a dot when empty
+	Separator string `json:"-"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// discoverResources lists the resource types on the server, in the same way
+// as httpclient.Client.ListResources, decoding the extended metadata.
+func discoverResources(ctx context.Context, cl *httpclient.Client, req schema.ListResourcesRequest) (*listResourcesResponse, error) {
+	var response listResourcesResponse
+	if err := cl.DoWithContext(ctx, nil, &response, client.OptPath("resource"), client.OptQuery(req.Query())); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// discoverAttributes fetches the attributes of each resource type which the
+// server listed as available without attributes, using up to workers concurrent
+// requests. Each worker has its own client, as a client makes one request at
+// a time. Resource types whose attributes cannot be fetched are omitted from
+// the result, and their errors returned keyed by resource type.
+func discoverAttributes(ctx context.Context, endpoint string, opts []client.ClientOpt, metas []resourceMeta, workers int) ([]resourceMeta, map[string]error) {
+	var pending []int
+	for i, meta := range metas {
+		if meta.Attributes == nil && (meta.Available == nil || *meta.Available) {
+			pending = append(pending, i)
+		}
+	}
+	if len(pending) == 0 {
+		return metas, nil
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	errs := map[string]error{}
+	fail := func(name string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs[name] = err
+	}
+
+	// Queue the resource types, and fetch them with a bounded number of workers
+	metas = slices.Clone(metas)
+	jobs := make(chan int, len(pending))
+	for _, i := range pending {
+		jobs <- i
+	}
+	close(jobs)
+	for range min(workers, len(pending)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cl, err := httpclient.New(endpoint, opts...)
+			for i := range jobs {
+				name := metas[i].Name
+				switch {
+				case err != nil:
+					fail(name, err)
+				case ctx.Err() != nil:
+					fail(name, ctx.Err())
+				default:
+					if meta, err := discoverResource(ctx, cl, name); err != nil {
+						fail(name, err)
+					} else {
+						metas[i] = *meta
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	return slices.DeleteFunc(metas, func(meta resourceMeta) bool {
+		_, failed := errs[meta.Name]
+		return failed
+	}), errs
+}
+
+// discoverResource returns a single resource type with its attributes.
+func discoverResource(ctx context.Context, cl *httpclient.Client, name string) (*resourceMeta, error) {
+	result, err := discoverResources(ctx, cl, schema.ListResourcesRequest{Type: &name})
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(result.Resources, func(meta resourceMeta) bool {
+		return meta.Name == name
+	})
+	if i < 0 {
+		return nil, fmt.Errorf("resource type %q not returned by the server", name)
+	}
+	if result.Resources[i].Attributes == nil {
+		result.Resources[i].Attributes = []attributeMeta{}
+	}
+	return &result.Resources[i], nil
+}
+
+// loadSchemaFile reads the resource types from a JSON file with the same
+// shape as the server's resource list, for discovery without a server.
+func loadSchemaFile(path string) ([]resourceMeta, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var response listResourcesResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return response.Resources, nil
+}
+
+// availableResources returns the resource types which the server does not
+// report as unavailable, for example because a feature flag is disabled,
+// logging the names of those skipped. Resource types without availability
+// information are kept.
+func availableResources(ctx context.Context, metas []resourceMeta) []resourceMeta {
+	var skipped []string
+	available := slices.DeleteFunc(slices.Clone(metas), func(meta resourceMeta) bool {
+		if meta.Available != nil && !*meta.Available {
+			skipped = append(skipped, meta.Name)
+			return true
+		}
+		return false
+	})
+	if len(skipped) > 0 {
+		slices.Sort(skipped)
+		logInfo(ctx, "Skipped resource types which the server reports as unavailable", map[string]interface{}{
+			"resources": strings.Join(skipped, ", "),
+		})
+	}
+	return available
+}
+
+// hash returns a digest of the discovered attribute set, independent of
+// the order the server lists attributes in.
+func (m resourceMeta) hash() string {
+	attrs := slices.Clone(m.Attributes)
+	slices.SortFunc(attrs, func(a, b attributeMeta) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	data, _ := json.Marshal(attrs)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// httpStatus returns the HTTP status code of an error returned by the
+// client, or zero if the error did not come from an HTTP response.
+func httpStatus(err error) int {
+	var status httpresponse.Err
+	if errors.As(err, &status) {
+		return int(status)
+	}
+	return 0
+}
+
+// getResourceInstance reads an instance in the same way as
+// httpclient.Client.GetResourceInstance, decoding any warnings.
+func getResourceInstance(ctx context.Context, cl *httpclient.Client, name string) (*getResourceInstanceResponse, error) {
+	var response getResourceInstanceResponse
+	if err := cl.DoWithContext(ctx, nil, &response, client.OptPath("resource", name)); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// getResourceInstances reads the named instances in one request to the
+// server's batch endpoint.
+func getResourceInstances(ctx context.Context, cl *httpclient.Client, names []string) (*batchGetResponse, error) {
+	request, err := client.NewJSONRequest(batchGetRequest{Names: names})
+	if err != nil {
+		return nil, err
+	}
+	var response batchGetResponse
+	if err := cl.DoWithContext(ctx, request, &response, client.OptPath("resource", "batch")); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// createResourceInstance creates an instance in the same way as
+// httpclient.Client.CreateResourceInstance, with a random idempotency key so
+// that a server which supports it can deduplicate a retry of the request.
+// When attributes are given, they are applied in the same request, as with a
+// following UpdateResourceInstance.
+func createResourceInstance(ctx context.Context, cl *httpclient.Client, name string, attrs schema.State) error {
+	request, err := client.NewJSONRequest(createResourceInstanceRequest{
+		CreateResourceInstanceRequest: schema.CreateResourceInstanceRequest{Name: name},
+		Attributes:                    attrs,
+		Apply:                         len(attrs) > 0,
+	})
+	if err != nil {
+		return err
+	}
+	var response schema.CreateResourceInstanceResponse
+	return cl.DoWithContext(ctx, request, &response, client.OptPath("resource"),
+		client.OptReqHeader(idempotencyKeyHeader, idempotencyKey()))
+}
+
+// updateResourceInstance updates an instance in the same way as
+// httpclient.Client.UpdateResourceInstance. When a version is given, the
+// server rejects the update if the instance has since been modified.
+func updateResourceInstance(ctx context.Context, cl *httpclient.Client, name string, req schema.UpdateResourceInstanceRequest, version string) error {
+	request, err := client.NewJSONRequestEx(http.MethodPatch, req, "")
+	if err != nil {
+		return err
+	}
+	var response schema.UpdateResourceInstanceResponse
+	return cl.DoWithContext(ctx, request, &response, append(versionOpts(version), client.OptPath("resource", name))...)
+}
+
+// renameResourceInstance renames an instance, keeping its attributes, for
+// servers which support it. When a version is given, the server rejects the
+// rename if the instance has since been modified.
+func renameResourceInstance(ctx context.Context, cl *httpclient.Client, name, newName, version string) error {
+	request, err := client.NewJSONRequest(renameResourceInstanceRequest{Name: newName})
+	if err != nil {
+		return err
+	}
+	var response schema.UpdateResourceInstanceResponse
+	return cl.DoWithContext(ctx, request, &response, append(versionOpts(version), client.OptPath("resource", name, "rename"))...)
+}
+
+// destroyResourceInstance destroys an instance in the same way as
+// httpclient.Client.DestroyResourceInstance. With the retain delete mode,
+// the server keeps the instance for recovery rather than purging it. When a
+// version is given, the server rejects the destroy if the instance has since
+// been modified.
+func destroyResourceInstance(ctx context.Context, cl *httpclient.Client, name string, cascade bool, mode string, version string) error {
+	request, err := client.NewJSONRequestEx(http.MethodDelete, nil, "")
+	if err != nil {
+		return err
+	}
+	opts := append(versionOpts(version), client.OptPath("resource", name))
+	query := url.Values{}
+	if cascade {
+		query.Set("cascade", "true")
+	}
+	if mode == deleteModeRetain {
+		query.Set("mode", deleteModeRetain)
+	}
+	if len(query) > 0 {
+		opts = append(opts, client.OptQuery(query))
+	}
+	var response schema.DestroyResourceInstanceResponse
+	return cl.DoWithContext(ctx, request, &response, opts...)
+}
+
+// versionOpts returns the request options which make a request conditional
+// on the instance version, or none when the version is empty.
+func versionOpts(version string) []client.RequestOpt {
+	if version == "" {
+		return nil
+	}
+	return []client.RequestOpt{client.OptReqHeader(ifMatchHeader, `"`+version+`"`)}
+}
+
+// idempotencyKey returns a random idempotency key for a create request. It
+// is the same only for retries of the request: a later create, including a
+// re-run of Terraform after a failed apply, has a new key, and is protected
+// only by the check for an existing instance with a configured label.
+func idempotencyKey() string {
+	return randomHex(16)
+}
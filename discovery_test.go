@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	// Packages
+	tftypes "github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+func TestRenameResourceInstance(t *testing.T) {
+	var got renameResourceInstanceRequest
+	var path, match string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			t.Errorf("method %q, want POST", req.Method)
+		}
+		path, match = req.URL.Path, req.Header.Get(ifMatchHeader)
+		if err := json.NewDecoder(req.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"instance":{"name":"x.b"}}`))
+	}))
+	defer srv.Close()
+
+	_, data := configure(t, map[string]tftypes.Value{"endpoint": stringValue(srv.URL)})
+	if err := renameResourceInstance(context.Background(), data.client, "x.a", "x.b", "3"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "/resource/x.a/rename"; !strings.HasSuffix(path, want) {
+		t.Errorf("path %q, want suffix %q", path, want)
+	}
+	if got.Name != "x.b" {
+		t.Errorf("new name %q, want %q", got.Name, "x.b")
+	}
+	if match != `"3"` {
+		t.Errorf("If-Match %q, want %q", match, `"3"`)
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	// A configured label may be reused, so each create has its own key
+	if idempotencyKey() == idempotencyKey() {
+		t.Error("idempotency keys for separate creates are equal")
+	}
+}
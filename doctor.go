@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	// Packages
+	client "github.com/mutablelogic/go-client"
+	httpclient "github.com/mutablelogic/go-server/pkg/provider/httpclient"
+	schema "github.com/mutablelogic/go-server/pkg/provider/schema"
+	oauth2 "golang.org/x/oauth2"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// doctor checks the provider settings from the environment against the
+// server, as the provider would use them in a Terraform run, and writes a
+// human-readable report to w. An error is returned if any check fails.
+func doctor(ctx context.Context, w io.Writer) error {
+	prof, err := resolveProfile("")
+	if err != nil {
+		fmt.Fprintf(w, "Profile:        FAILED (%s)\n", err)
+		return err
+	}
+	apiKey, err := resolveApiKey(prof)
+	if err != nil {
+		fmt.Fprintf(w, "API key:        FAILED (%s)\n", err)
+		return err
+	}
+	var tokens oauth2.TokenSource
+	if oauth := resolveOAuth(); oauth != nil {
+		tokens = oauth.TokenSource(ctx)
+		if _, err := tokens.Token(); err != nil {
+			fmt.Fprintf(w, "OAuth2 token:   FAILED (%s)\n", err)
+			return err
+		}
+		fmt.Fprintln(w, "OAuth2 token:   ok")
+	}
+	signer, err := resolveSigner()
+	if err != nil {
+		fmt.Fprintf(w, "Signing:        FAILED (%s)\n", err)
+		return err
+	}
+	opts := clientOpts(apiKey, resolveKeyAuth(), userAgent(version, "", "doctor"), tokens, signer, defaultMaxResponseSize)
+
+	// Check the default endpoint and every endpoint override
+	endpoints := []string{resolveEndpoint(prof, 0)}
+	for _, override := range resolveEndpoints() {
+		endpoints = append(endpoints, override)
+	}
+
+	var failed bool
+	for _, endpoint := range endpoints {
+		fmt.Fprintf(w, "\nEndpoint:       %s\n", endpoint)
+		if !doctorEndpoint(ctx, w, endpoint, opts) {
+			failed = true
+		}
+	}
+	if failed {
+		return errors.New("one or more checks failed")
+	}
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// doctorEndpoint checks one endpoint, writing the report to w, and returns
+// false if any check fails.
+func doctorEndpoint(ctx context.Context, w io.Writer, endpoint string, opts []client.ClientOpt) bool {
+	cl, err := httpclient.New(endpoint, opts...)
+	if err != nil {
+		fmt.Fprintf(w, "Client:         FAILED (%s)\n", err)
+		return false
+	}
+
+	result, err := discoverResources(ctx, cl, schema.ListResourcesRequest{})
+	switch status := httpStatus(err); {
+	case err == nil:
+		fmt.Fprintln(w, "Reachable:      yes")
+		fmt.Fprintln(w, "Authenticated:  yes")
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		fmt.Fprintln(w, "Reachable:      yes")
+		fmt.Fprintf(w, "Authenticated:  FAILED (%s)\n", err)
+		return false
+	case status != 0:
+		fmt.Fprintln(w, "Reachable:      yes")
+		fmt.Fprintf(w, "Discovery:      FAILED (%s)\n", err)
+		return false
+	default:
+		fmt.Fprintf(w, "Reachable:      FAILED (%s)\n", err)
+		return false
+	}
+
+	// Build the schema of each resource type, reporting any problems
+	ok := true
+	sort.Slice(result.Resources, func(i, j int) bool {
+		return result.Resources[i].Name < result.Resources[j].Name
+	})
+	fmt.Fprintf(w, "Resource types: %d\n", len(result.Resources))
+	settings := resolveSchemaSettings(ctx)
+	for _, meta := range result.Resources {
+		_, _, diags := buildResourceSchema(settings.apply(meta))
+		if diags.HasError() {
+			ok = false
+			fmt.Fprintf(w, "  %-20s FAILED\n", meta.Name)
+			for _, d := range diags.Errors() {
+				fmt.Fprintf(w, "    %s: %s\n", d.Summary(), d.Detail())
+			}
+			continue
+		}
+		if meta.Available != nil && !*meta.Available {
+			fmt.Fprintf(w, "  %-20s skipped (reported unavailable by the server)\n", meta.Name)
+			continue
+		}
+		fmt.Fprintf(w, "  %-20s ok (%d attributes)\n", meta.Name, len(meta.Attributes))
+		for _, a := range meta.Attributes {
+			if !isKnownType(a.Type) {
+				fmt.Fprintf(w, "    warning: attribute %q has unknown type %q, represented as a string\n", a.Name, a.Type)
+			}
+		}
+	}
+	return ok
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	// Packages
+	datasource "github.com/hashicorp/terraform-plugin-framework/datasource"
+	tfschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	types "github.com/hashicorp/terraform-plugin-framework/types"
+	client "github.com/mutablelogic/go-client"
+	schema "github.com/mutablelogic/go-server/pkg/provider/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// instanceHistoryDataSource implements the kaiak_instance_history data source.
+type instanceHistoryDataSource struct {
+	data *providerData
+}
+
+// instanceHistoryDataSourceModel maps the data source schema to Go types.
+type instanceHistoryDataSourceModel struct {
+	ID        types.String              `tfsdk:"id"`
+	Revisions []revisionDataSourceModel `tfsdk:"revisions"`
+}
+
+// revisionDataSourceModel describes a single revision of an instance.
+type revisionDataSourceModel struct {
+	Revision  types.Int64  `tfsdk:"revision"`
+	Timestamp types.String `tfsdk:"timestamp"`
+	State     types.String `tfsdk:"state"`
+}
+
+// instanceHistoryResponse is returned by the server's history endpoint.
+type instanceHistoryResponse struct {
+	Name      string             `json:"name"`
+	Revisions []instanceRevision `json:"revisions"`
+}
+
+// instanceRevision is a prior state of an instance.
+type instanceRevision struct {
+	Revision  int64        `json:"revision"`
+	Timestamp time.Time    `json:"timestamp"`
+	State     schema.State `json:"state,omitempty"`
+}
+
+var _ datasource.DataSource = (*instanceHistoryDataSource)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+func NewInstanceHistoryDataSource() datasource.DataSource {
+	return &instanceHistoryDataSource{}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// DATA SOURCE INTERFACE
+
+func (d *instanceHistoryDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_instance_history"
+}
+
+func (d *instanceHistoryDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = tfschema.Schema{
+		Description: "Lists the prior revisions of an instance on a running Kaiak server.",
+		Attributes: map[string]tfschema.Attribute{
+			"id": tfschema.StringAttribute{
+				Description: "The fully qualified instance name (e.g. \"httpserver.main\").",
+				Required:    true,
+			},
+			"revisions": tfschema.ListNestedAttribute{
+				Description: "Prior revisions of the instance, as reported by the server. Empty when the " +
+					"server does not track history.",
+				Computed: true,
+				NestedObject: tfschema.NestedAttributeObject{
+					Attributes: map[string]tfschema.Attribute{
+						"revision": tfschema.Int64Attribute{
+							Description: "Revision number.",
+							Computed:    true,
+						},
+						"timestamp": tfschema.StringAttribute{
+							Description: "When the revision was made, in RFC 3339 format.",
+							Computed:    true,
+						},
+						"state": tfschema.StringAttribute{
+							Description: "The instance state at this revision, JSON encoded. Sensitive, as it " +
+								"holds the values of sensitive attributes in plain text.",
+							Computed:  true,
+							Sensitive: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *instanceHistoryDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data type",
+			fmt.Sprintf("Expected *providerData, got %T", req.ProviderData))
+		return
+	}
+	d.data = data
+}
+
+func (d *instanceHistoryDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.data == nil {
+		resp.Diagnostics.AddError("Data source not configured",
+			"The provider has not been configured. Ensure the provider block is present and valid.")
+		return
+	}
+
+	var config instanceHistoryDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	name := config.ID.ValueString()
+	resourceType, _, err := parseInstanceName(name)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid instance id", err.Error())
+		return
+	}
+	cl := d.data.clientFor(resourceType)
+
+	// Check the instance exists, so a missing history endpoint can be told apart
+	if _, err := cl.GetResourceInstance(ctx, name); err != nil {
+		resp.Diagnostics.AddError("Failed to read resource instance", err.Error())
+		return
+	}
+
+	var history instanceHistoryResponse
+	config.Revisions = []revisionDataSourceModel{}
+	if err := cl.DoWithContext(ctx, nil, &history, client.OptPath("resource", name, "history")); err != nil {
+		switch httpStatus(err) {
+		case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusNotImplemented:
+			resp.Diagnostics.AddWarning("Instance history not supported",
+				fmt.Sprintf("The server does not provide history for %q, so no revisions are returned: %s", name, err))
+		default:
+			resp.Diagnostics.AddError("Failed to read instance history", err.Error())
+			return
+		}
+	}
+
+	// Map the response into the model
+	for _, rev := range history.Revisions {
+		state, err := json.Marshal(rev.State)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to encode revision state", err.Error())
+			return
+		}
+		config.Revisions = append(config.Revisions, revisionDataSourceModel{
+			Revision:  types.Int64Value(rev.Revision),
+			Timestamp: types.StringValue(rev.Timestamp.Format(time.RFC3339)),
+			State:     types.StringValue(string(state)),
+		})
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	// Packages
+	client "github.com/mutablelogic/go-client"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// limitingTransport caps the size of the response bodies read from the
+// server, so that a misbehaving server cannot exhaust the memory of the
+// provider.
+type limitingTransport struct {
+	maxSize int64
+	base    http.RoundTripper
+}
+
+// limitedBody is a response body which fails with errResponseTooLarge once
+// more than its maximum size has been read.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	err       error
+}
+
+// errResponseTooLarge is returned when reading a response body larger than
+// the maximum size.
+type errResponseTooLarge struct {
+	method  string
+	url     string
+	maxSize int64
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// defaultMaxResponseSize is the default maximum size of a response body,
+// well above any instance or schema a server is expected to return.
+const defaultMaxResponseSize = 64 << 20
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// RoundTrip sends the request and limits the size of the response body. A
+// body with a Content-Length above the maximum fails on the first read,
+// without reading any of it.
+func (t *limitingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	response, err := t.base.RoundTrip(req)
+	if err != nil || response.Body == nil || response.Body == http.NoBody {
+		return response, err
+	}
+	body := &limitedBody{
+		ReadCloser: response.Body,
+		remaining:  t.maxSize,
+		err:        errResponseTooLarge{method: req.Method, url: req.URL.Redacted(), maxSize: t.maxSize},
+	}
+	if response.ContentLength > t.maxSize {
+		body.remaining = -1
+	}
+	response.Body = body
+	return response, nil
+}
+
+// Read reads from the body, failing once more than the maximum size has been
+// read.
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, b.err
+	}
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n + int(b.remaining), b.err
+	}
+	return n, err
+}
+
+func (e errResponseTooLarge) Error() string {
+	return fmt.Sprintf("the response to %s %s exceeds the maximum size of %d bytes; raise max_response_size if the server legitimately returns responses this large",
+		e.method, e.url, e.maxSize)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// optMaxResponseSize limits the size of response bodies to maxSize bytes.
+func optMaxResponseSize(maxSize int64) client.ClientOpt {
+	return func(c *client.Client) error {
+		c.Transport = &limitingTransport{maxSize: maxSize, base: c.Transport}
+		return nil
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	// Packages
+	tflog "github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// logLevel is the severity of a provider log line.
+type logLevel int32
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	levelTrace logLevel = iota
+	levelDebug
+	levelInfo
+	levelWarn
+	levelError
+	levelOff
+)
+
+// logLevels maps KAIAK_LOG_LEVEL values to levels.
+var logLevels = map[string]logLevel{
+	"TRACE": levelTrace,
+	"DEBUG": levelDebug,
+	"INFO":  levelInfo,
+	"WARN":  levelWarn,
+	"ERROR": levelError,
+	"OFF":   levelOff,
+}
+
+// minLogLevel is the minimum level of the provider's own log lines. Lines
+// below it are dropped before they reach tflog.
+var minLogLevel atomic.Int32
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// setLogLevel sets the minimum log level from KAIAK_LOG_LEVEL. When it is
+// unset or not a known level, every line is passed to tflog.
+func setLogLevel() {
+	level, ok := logLevels[strings.ToUpper(strings.TrimSpace(os.Getenv("KAIAK_LOG_LEVEL")))]
+	if !ok {
+		level = levelTrace
+	}
+	minLogLevel.Store(int32(level))
+}
+
+func logDebug(ctx context.Context, msg string, fields ...map[string]interface{}) {
+	if logEnabled(levelDebug) {
+		tflog.Debug(ctx, msg, fields...)
+	}
+}
+
+func logInfo(ctx context.Context, msg string, fields ...map[string]interface{}) {
+	if logEnabled(levelInfo) {
+		tflog.Info(ctx, msg, fields...)
+	}
+}
+
+func logWarn(ctx context.Context, msg string, fields ...map[string]interface{}) {
+	if logEnabled(levelWarn) {
+		tflog.Warn(ctx, msg, fields...)
+	}
+}
+
+func logError(ctx context.Context, msg string, fields ...map[string]interface{}) {
+	if logEnabled(levelError) {
+		tflog.Error(ctx, msg, fields...)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// logEnabled returns true if lines at the given level are logged.
+func logEnabled(level logLevel) bool {
+	return level >= logLevel(minLogLevel.Load())
+}
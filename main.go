@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"log"
+	"os"
 
 	// Packages
 	providerserver "github.com/hashicorp/terraform-plugin-framework/providerserver"
@@ -13,24 +14,59 @@ import (
 // GLOBALS
 
 const (
-	providerAddress = "registry.terraform.io/mutablelogic/kaiak"
+	defaultProviderAddress = "registry.terraform.io/mutablelogic/kaiak"
 )
 
 // version is set at build time via ldflags.
 var version string
 
+// address may be set at build time via ldflags to serve the provider under
+// a different registry namespace (e.g. a private mirror).
+var address string
+
 ///////////////////////////////////////////////////////////////////////////////
 // MAIN
 
 func main() {
-	var debug bool
+	var debug, doctorMode bool
+	var schemaType string
 	flag.BoolVar(&debug, "debug", false, "Start provider in debug mode (set TF_REATTACH_PROVIDERS to connect)")
+	flag.BoolVar(&doctorMode, "doctor", false, "Check the server connection and schemas using the KAIAK_* environment variables, then exit")
+	flag.StringVar(&schemaType, "schema", "", "Print the Terraform schema generated for a resource type, discovered using the KAIAK_* environment variables, then exit")
 	flag.Parse()
 
+	// Report on the setup rather than serving, exiting non-zero on failure
+	if doctorMode {
+		if err := doctor(context.Background(), os.Stdout); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Print the generated schema rather than serving, exiting non-zero on failure
+	if schemaType != "" {
+		if err := previewSchema(context.Background(), os.Stdout, schemaType); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := providerserver.Serve(context.Background(), New(version), providerserver.ServeOpts{
-		Address: providerAddress,
+		Address: resolveAddress(),
 		Debug:   debug,
 	}); err != nil {
 		log.Fatal(err)
 	}
 }
+
+// resolveAddress returns the registry address the provider is served under:
+// KAIAK_PROVIDER_ADDRESS environment variable > build-time address > default.
+func resolveAddress() string {
+	if v := os.Getenv("KAIAK_PROVIDER_ADDRESS"); v != "" {
+		return v
+	}
+	if address != "" {
+		return address
+	}
+	return defaultProviderAddress
+}
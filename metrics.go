@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// latencyStats accumulates operation latencies per resource type across
+// a provider run, so slow-to-converge resource types can be identified.
+type latencyStats struct {
+	sync.Mutex
+	samples map[string][]time.Duration // "resource_type/operation" → latencies
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+func newLatencyStats() *latencyStats {
+	return &latencyStats{samples: make(map[string][]time.Duration)}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// observe starts timing an operation and returns a function which records
+// the elapsed time when called. Each recording logs the cumulative summary,
+// so the last summary line in the log covers the whole run. It is safe to
+// call on a nil receiver, in which case nothing is recorded.
+func (s *latencyStats) observe(ctx context.Context, resourceType, op string) func() {
+	if s == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		s.record(resourceType+"/"+op, time.Since(start))
+		logInfo(ctx, "Kaiak operation latency summary", s.summary())
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+func (s *latencyStats) record(key string, d time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+	s.samples[key] = append(s.samples[key], d)
+}
+
+// summary returns the count and p50/p95 latency for each resource type
+// and operation as structured log fields.
+func (s *latencyStats) summary() map[string]interface{} {
+	s.Lock()
+	defer s.Unlock()
+	fields := make(map[string]interface{}, len(s.samples))
+	for key, samples := range s.samples {
+		sorted := append([]time.Duration(nil), samples...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		fields[key] = fmt.Sprintf("count=%d p50=%s p95=%s",
+			len(sorted), percentile(sorted, 0.50), percentile(sorted, 0.95))
+	}
+	return fields
+}
+
+// percentile returns the nearest-rank percentile p (0-1) of sorted samples.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	// Packages
+	attr "github.com/hashicorp/terraform-plugin-framework/attr"
+	diag "github.com/hashicorp/terraform-plugin-framework/diag"
+	basetypes "github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	tftypes "github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// normalizedStringType is a string type whose values compare equal when
+// they are equal after normalization. The framework uses this semantic
+// equality to keep the configured value in state when the server returns
+// an equivalent canonical form, avoiding perpetual diffs.
+type normalizedStringType struct {
+	basetypes.StringType
+	normalize []string // names of normalizers, applied in order
+}
+
+// normalizedStringValue is a value of normalizedStringType.
+type normalizedStringValue struct {
+	basetypes.StringValue
+	normalize []string
+}
+
+var _ basetypes.StringTypable = normalizedStringType{}
+var _ basetypes.StringValuableWithSemanticEquals = normalizedStringValue{}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// normalizers canonicalize a string value before comparison.
+var normalizers = map[string]func(string) string{
+	"trimspace": strings.TrimSpace,
+	"lower":     strings.ToLower,
+	"duration":  normalizeDuration,
+}
+
+// typeNormalizers are applied by default to attributes of a kaiak type.
+var typeNormalizers = map[string][]string{
+	"duration": {"duration"},
+}
+
+// nameNormalizers are applied by default to attributes with a field name.
+var nameNormalizers = map[string][]string{
+	"host":     {"trimspace", "lower"},
+	"hostname": {"trimspace", "lower"},
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// attrNormalizers returns the normalizers for an attribute: those named in
+// the server metadata, or otherwise the defaults for its type and name.
+// Unknown normalizer names are ignored.
+func attrNormalizers(a attributeMeta) []string {
+	names := a.Normalize
+	if len(names) == 0 {
+		field := a.Name
+		if sep := a.separator(); strings.Contains(field, sep) {
+			field = field[strings.LastIndex(field, sep)+len(sep):]
+		}
+		names = append(slices.Clone(typeNormalizers[a.Type]), nameNormalizers[field]...)
+	}
+	return slices.DeleteFunc(slices.Clone(names), func(name string) bool {
+		_, ok := normalizers[name]
+		return !ok
+	})
+}
+
+// normalizeString applies the named normalizers to a value in order.
+func normalizeString(names []string, s string) string {
+	for _, name := range names {
+		if fn, ok := normalizers[name]; ok {
+			s = fn(s)
+		}
+	}
+	return s
+}
+
+// toStringValue returns the string value of a plain or custom string value.
+func toStringValue(ctx context.Context, v attr.Value) (basetypes.StringValue, bool) {
+	if sv, ok := v.(basetypes.StringValuable); ok {
+		if s, diags := sv.ToStringValue(ctx); !diags.HasError() {
+			return s, true
+		}
+	}
+	return basetypes.StringValue{}, false
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPE INTERFACE
+
+func (t normalizedStringType) Equal(o attr.Type) bool {
+	other, ok := o.(normalizedStringType)
+	return ok && slices.Equal(t.normalize, other.normalize)
+}
+
+func (t normalizedStringType) String() string {
+	return fmt.Sprintf("normalizedStringType%v", t.normalize)
+}
+
+func (t normalizedStringType) ValueFromString(_ context.Context, in basetypes.StringValue) (basetypes.StringValuable, diag.Diagnostics) {
+	return normalizedStringValue{StringValue: in, normalize: t.normalize}, nil
+}
+
+func (t normalizedStringType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	v, err := t.StringType.ValueFromTerraform(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	sv, ok := v.(basetypes.StringValue)
+	if !ok {
+		return nil, fmt.Errorf("unexpected value type %T", v)
+	}
+	return normalizedStringValue{StringValue: sv, normalize: t.normalize}, nil
+}
+
+func (t normalizedStringType) ValueType(_ context.Context) attr.Value {
+	return normalizedStringValue{normalize: t.normalize}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// VALUE INTERFACE
+
+func (v normalizedStringValue) Type(_ context.Context) attr.Type {
+	return normalizedStringType{normalize: v.normalize}
+}
+
+func (v normalizedStringValue) Equal(o attr.Value) bool {
+	other, ok := o.(normalizedStringValue)
+	return ok && v.StringValue.Equal(other.StringValue)
+}
+
+func (v normalizedStringValue) StringSemanticEquals(_ context.Context, newValuable basetypes.StringValuable) (bool, diag.Diagnostics) {
+	other, ok := newValuable.(normalizedStringValue)
+	if !ok {
+		return false, nil
+	}
+	return normalizeString(v.normalize, v.ValueString()) == normalizeString(v.normalize, other.ValueString()), nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// normalizeDuration returns the canonical form of a Go duration string
+// (e.g. "60s" becomes "1m0s"), or the value unchanged if it does not parse.
+func normalizeDuration(s string) string {
+	if d, err := time.ParseDuration(strings.TrimSpace(s)); err == nil {
+		return d.String()
+	}
+	return s
+}
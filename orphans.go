@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	// Packages
+	datasource "github.com/hashicorp/terraform-plugin-framework/datasource"
+	tfschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	types "github.com/hashicorp/terraform-plugin-framework/types"
+	httpclient "github.com/mutablelogic/go-server/pkg/provider/httpclient"
+	schema "github.com/mutablelogic/go-server/pkg/provider/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// orphansDataSource implements the kaiak_orphans data source.
+type orphansDataSource struct {
+	data *providerData
+}
+
+// orphansDataSourceModel maps the data source schema to Go types.
+type orphansDataSourceModel struct {
+	Type      types.String `tfsdk:"type"`
+	Managed   types.List   `tfsdk:"managed"`
+	Instances types.List   `tfsdk:"instances"`
+}
+
+var _ datasource.DataSource = (*orphansDataSource)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+func NewOrphansDataSource() datasource.DataSource {
+	return &orphansDataSource{}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// DATA SOURCE INTERFACE
+
+func (d *orphansDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_orphans"
+}
+
+func (d *orphansDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = tfschema.Schema{
+		Description: "Lists instances on a running Kaiak server which were created by the provider, " +
+			"but are not among the managed instances given. These are typically left behind when " +
+			"an apply failed and cleanup of a partly created instance also failed.",
+		Attributes: map[string]tfschema.Attribute{
+			"type": tfschema.StringAttribute{
+				Description: "Filter by resource type name (e.g. \"httpserver\"). Omit for all types.",
+				Optional:    true,
+			},
+			"managed": tfschema.ListAttribute{
+				Description: "Names of the instances in Terraform state (e.g. the id of each kaiak resource), " +
+					"which are not reported as orphans.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"instances": tfschema.ListAttribute{
+				Description: "Names of the orphaned instances (e.g. \"httpserver.tf_1a2b3c4d\"), sorted.",
+				ElementType: types.StringType,
+				Computed:    true,
+			},
+		},
+	}
+}
+
+func (d *orphansDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data type",
+			fmt.Sprintf("Expected *providerData, got %T", req.ProviderData))
+		return
+	}
+	d.data = data
+}
+
+func (d *orphansDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.data == nil {
+		resp.Diagnostics.AddError("Data source not configured",
+			"The provider has not been configured. Ensure the provider block is present and valid.")
+		return
+	}
+
+	var config orphansDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if config.Type.IsUnknown() || config.Managed.IsUnknown() {
+		resp.Diagnostics.AddError("Unknown filter",
+			"The \"type\" and \"managed\" attributes must be known. This data source cannot be read "+
+				"during plan when they depend on another resource's output.")
+		return
+	}
+	var managed []string
+	if !config.Managed.IsNull() {
+		resp.Diagnostics.Append(config.Managed.ElementsAs(ctx, &managed, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	// List the instances on each endpoint, counting only the resource types it serves
+	listReq := schema.ListResourcesRequest{}
+	if !config.Type.IsNull() {
+		t := config.Type.ValueString()
+		listReq.Type = &t
+	}
+	clients := []*httpclient.Client{d.data.client}
+	for _, cl := range d.data.overrides {
+		if !slices.Contains(clients, cl) {
+			clients = append(clients, cl)
+		}
+	}
+	orphans := []string{}
+	for _, cl := range clients {
+		result, err := discoverResources(ctx, cl, listReq)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to list resources", err.Error())
+			return
+		}
+		for _, meta := range result.Resources {
+			if d.data.clientFor(meta.Name) != cl {
+				continue
+			}
+			for _, instance := range meta.Instances {
+				_, label, err := parseInstanceName(instance.Name)
+				if err != nil || !isGeneratedLabel(label) || slices.Contains(managed, instance.Name) {
+					continue
+				}
+				orphans = append(orphans, instance.Name)
+			}
+		}
+	}
+	slices.Sort(orphans)
+
+	instances, diags := types.ListValueFrom(ctx, types.StringType, orphans)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	config.Instances = instances
+	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// pollFunc is called repeatedly by poll until it returns true or an error.
+type pollFunc func(context.Context) (bool, error)
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+var (
+	// errPollTimeout is returned by poll when the context deadline is exceeded
+	errPollTimeout = errors.New("timed out waiting for condition")
+
+	// errPollCanceled is returned by poll when the context is canceled
+	errPollCanceled = errors.New("canceled while waiting for condition")
+)
+
+// pollAfter returns a channel which receives once the duration has elapsed.
+// It can be replaced to drive poll from a fake clock.
+var pollAfter = time.After
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// poll calls fn until it returns true, waiting a jittered interval between
+// calls. It returns nil when fn succeeds, the error from fn unchanged if it
+// fails, errPollTimeout when the context deadline is exceeded, or
+// errPollCanceled when the context is canceled. The timeout and cancellation
+// errors also wrap the context error.
+func poll(ctx context.Context, interval time.Duration, fn pollFunc) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return pollContextError(err)
+		}
+		if done, err := fn(ctx); err != nil {
+			return err
+		} else if done {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return pollContextError(ctx.Err())
+		case <-pollAfter(jitter(interval)):
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// pollContextError maps a context error to the corresponding poll error.
+func pollContextError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", errPollTimeout, err)
+	}
+	return fmt.Errorf("%w: %w", errPollCanceled, err)
+}
+
+// jitter returns the interval adjusted randomly by up to ±20%, so that
+// concurrent pollers do not call the server in lockstep.
+func jitter(interval time.Duration) time.Duration {
+	if spread := int64(interval) * 2 / 5; spread > 0 {
+		return interval - time.Duration(spread/2) + time.Duration(rand.Int64N(spread+1))
+	}
+	return interval
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+// fakeClock replaces pollAfter for the duration of the test, recording each
+// wait. A wait elapses at once, or never when the clock is stopped.
+type fakeClock struct {
+	waits   []time.Duration
+	stopped bool
+	onWait  func() // called on each wait, when set
+}
+
+// newFakeClock returns a fake clock which drives poll until the test ends.
+func newFakeClock(t *testing.T) *fakeClock {
+	t.Helper()
+	c := &fakeClock{}
+	after := pollAfter
+	t.Cleanup(func() { pollAfter = after })
+	pollAfter = c.after
+	return c
+}
+
+func (c *fakeClock) after(d time.Duration) <-chan time.Time {
+	c.waits = append(c.waits, d)
+	if c.onWait != nil {
+		c.onWait()
+	}
+	ch := make(chan time.Time, 1)
+	if !c.stopped {
+		ch <- time.Time{}
+	}
+	return ch
+}
+
+func TestPoll(t *testing.T) {
+	clock := newFakeClock(t)
+	interval := 10 * time.Second
+	calls := 0
+	err := poll(context.Background(), interval, func(context.Context) (bool, error) {
+		calls++
+		return calls == 3, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 || len(clock.waits) != 2 {
+		t.Errorf("%d calls and %d waits, want 3 and 2", calls, len(clock.waits))
+	}
+	for _, d := range clock.waits {
+		if d < interval*4/5 || d > interval*6/5 {
+			t.Errorf("waited %v, want within 20%% of %v", d, interval)
+		}
+	}
+}
+
+func TestPollError(t *testing.T) {
+	newFakeClock(t)
+	want := errors.New("failed")
+	err := poll(context.Background(), time.Second, func(context.Context) (bool, error) {
+		return false, want
+	})
+	if err != want {
+		t.Errorf("error %v, want %v", err, want)
+	}
+}
+
+func TestPollCanceled(t *testing.T) {
+	// The context is canceled while waiting
+	clock := newFakeClock(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clock.stopped, clock.onWait = true, cancel
+	err := poll(ctx, time.Second, func(context.Context) (bool, error) {
+		return false, nil
+	})
+	if !errors.Is(err, errPollCanceled) || !errors.Is(err, context.Canceled) {
+		t.Errorf("error %v, want %v", err, errPollCanceled)
+	}
+	if errors.Is(err, errPollTimeout) {
+		t.Errorf("error %v is a timeout", err)
+	}
+}
+
+func TestPollTimeout(t *testing.T) {
+	// The deadline has passed before the first call
+	newFakeClock(t)
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	calls := 0
+	err := poll(ctx, time.Second, func(context.Context) (bool, error) {
+		calls++
+		return false, nil
+	})
+	if !errors.Is(err, errPollTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error %v, want %v", err, errPollTimeout)
+	}
+	if calls != 0 {
+		t.Errorf("%d calls after the deadline", calls)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	// Packages
+	resource "github.com/hashicorp/terraform-plugin-framework/resource"
+	tfschema "github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	tftypes "github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// previewSchema discovers resource types with the KAIAK_* environment
+// variables, as the provider would in a Terraform run, and writes the
+// Terraform schema generated for the named resource type to w. Problems
+// building the schema, such as colliding or reserved attribute names, are
+// written as well, and an error is returned if the schema cannot be built.
+func previewSchema(ctx context.Context, w io.Writer, resourceType string) error {
+	metas, err := (&kaiakProvider{version: version}).discover(ctx)
+	if err != nil && len(metas) == 0 {
+		fmt.Fprintf(w, "Discovery: FAILED (%s)\n", err)
+		return err
+	} else if err != nil {
+		fmt.Fprintf(w, "Discovery: incomplete (%s)\n", err)
+	}
+
+	// Find the resource type, by its name with or without the provider prefix
+	resourceType = strings.TrimPrefix(resourceType, "kaiak_")
+	var names []string
+	for _, factory := range resourceFactories(metas, resolveSchemaSettings(ctx)) {
+		r := factory()
+		var metadata resource.MetadataResponse
+		r.Metadata(ctx, resource.MetadataRequest{ProviderTypeName: "kaiak"}, &metadata)
+		if metadata.TypeName != "kaiak_"+resourceType {
+			names = append(names, strings.TrimPrefix(metadata.TypeName, "kaiak_"))
+			continue
+		}
+
+		var response resource.SchemaResponse
+		r.Schema(ctx, resource.SchemaRequest{}, &response)
+		for _, d := range response.Diagnostics {
+			fmt.Fprintf(w, "%s: %s: %s\n", d.Severity(), d.Summary(), d.Detail())
+		}
+		if response.Diagnostics.HasError() {
+			return errors.New("the schema cannot be built")
+		}
+		fmt.Fprintf(w, "Resource: %s\n", metadata.TypeName)
+		fmt.Fprintf(w, "%s\n\n", response.Schema.Description)
+		writeAttributes(ctx, w, response.Schema.Attributes, "  ")
+		return nil
+	}
+
+	slices.Sort(names)
+	err = fmt.Errorf("no resource type %q", resourceType)
+	fmt.Fprintf(w, "FAILED: %s. Available resource types: %s\n", err, strings.Join(names, ", "))
+	return err
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// writeAttributes writes a line for each attribute, sorted by name, with
+// its type and flags, followed by the members of a nested block.
+func writeAttributes(ctx context.Context, w io.Writer, attrs map[string]tfschema.Attribute, indent string) {
+	for _, name := range slices.Sorted(maps.Keys(attrs)) {
+		a := attrs[name]
+		var flags []string
+		for _, flag := range []struct {
+			name string
+			set  bool
+		}{
+			{"required", a.IsRequired()},
+			{"optional", a.IsOptional()},
+			{"computed", a.IsComputed()},
+			{"sensitive", a.IsSensitive()},
+			{"deprecated", a.GetDeprecationMessage() != ""},
+		} {
+			if flag.set {
+				flags = append(flags, flag.name)
+			}
+		}
+		fmt.Fprintf(w, "%s%-*s %-16s %s\n", indent, 28-len(indent), name,
+			typeName(a.GetType().TerraformType(ctx)), strings.Join(flags, ", "))
+		if block, ok := a.(tfschema.SingleNestedAttribute); ok {
+			writeAttributes(ctx, w, block.Attributes, indent+"  ")
+		}
+	}
+}
+
+// typeName returns the name of a Terraform type as written in configuration
+// (e.g. "list(string)").
+func typeName(t tftypes.Type) string {
+	switch {
+	case t.Is(tftypes.String):
+		return "string"
+	case t.Is(tftypes.Number):
+		return "number"
+	case t.Is(tftypes.Bool):
+		return "bool"
+	}
+	switch t := t.(type) {
+	case tftypes.List:
+		return "list(" + typeName(t.ElementType) + ")"
+	case tftypes.Set:
+		return "set(" + typeName(t.ElementType) + ")"
+	case tftypes.Map:
+		return "map(" + typeName(t.ElementType) + ")"
+	case tftypes.Object:
+		return "object"
+	}
+	return t.String()
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// profile holds the connection settings of a named section of the config
+// file, which take precedence over environment variables.
+type profile struct {
+	Endpoint   string
+	APIKey     string
+	APIKeyFile string
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// defaultConfigFile is the config file, relative to the home directory,
+// unless KAIAK_CONFIG_FILE is set.
+const defaultConfigFile = ".kaiak/config"
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// resolveProfile returns the named profile, or when name is empty the one
+// named by KAIAK_PROFILE, from the config file. An empty profile is returned
+// when no profile is named.
+func resolveProfile(name string) (profile, error) {
+	if name == "" {
+		name = os.Getenv("KAIAK_PROFILE")
+	}
+	if name == "" {
+		return profile{}, nil
+	}
+	path := os.Getenv("KAIAK_CONFIG_FILE")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return profile{}, err
+		}
+		path = filepath.Join(home, defaultConfigFile)
+	}
+	return loadProfile(path, name)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// loadProfile reads the named section of an INI-style config file, in which
+// each section holds "key = value" lines, and lines starting with "#" or ";"
+// are comments. Values may be double-quoted. An error is returned if the
+// section does not exist or has an unknown key.
+func loadProfile(path, name string) (profile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return profile{}, err
+	}
+
+	var result profile
+	var section string
+	var found bool
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			found = found || section == name
+			continue
+		case section != name:
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return profile{}, fmt.Errorf("%s:%d: expected \"key = value\"", path, i+1)
+		}
+		value = strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil && strings.HasPrefix(value, `"`) {
+			value = unquoted
+		}
+		switch key = strings.TrimSpace(key); key {
+		case "endpoint":
+			result.Endpoint = value
+		case "api_key":
+			result.APIKey = value
+		case "api_key_file":
+			result.APIKeyFile = value
+		default:
+			return profile{}, fmt.Errorf("%s:%d: unknown key %q in profile %q", path, i+1, key, name)
+		}
+	}
+	if !found {
+		return profile{}, fmt.Errorf("%s: profile %q not found", path, name)
+	}
+	return result, nil
+}
@@ -2,18 +2,37 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	// Packages
+	int64validator "github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	listvalidator "github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	stringvalidator "github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	attr "github.com/hashicorp/terraform-plugin-framework/attr"
 	datasource "github.com/hashicorp/terraform-plugin-framework/datasource"
+	diag "github.com/hashicorp/terraform-plugin-framework/diag"
+	path "github.com/hashicorp/terraform-plugin-framework/path"
 	provider "github.com/hashicorp/terraform-plugin-framework/provider"
 	tfschema "github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	resource "github.com/hashicorp/terraform-plugin-framework/resource"
+	validator "github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	types "github.com/hashicorp/terraform-plugin-framework/types"
-	tflog "github.com/hashicorp/terraform-plugin-log/tflog"
 	client "github.com/mutablelogic/go-client"
 	httpclient "github.com/mutablelogic/go-server/pkg/provider/httpclient"
 	schema "github.com/mutablelogic/go-server/pkg/provider/schema"
+	oauth2 "golang.org/x/oauth2"
+	clientcredentials "golang.org/x/oauth2/clientcredentials"
 )
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -21,18 +40,118 @@ import (
 
 // kaiakProvider implements the Terraform provider for a running Kaiak server.
 type kaiakProvider struct {
-	version  string
-	endpoint string // resolved during Configure; used by Resources for discovery
-	apiKey   string // resolved during Configure; used by Resources for discovery
+	version   string
+	endpoint  string                    // resolved during Configure; used by Resources for discovery
+	apiKey    string                    // resolved during Configure; used by Resources for discovery
+	endpoints map[string]string         // resolved during Configure; per-type endpoint overrides
+	userAgent string                    // resolved during Configure; used by Resources for discovery
+	tokens    oauth2.TokenSource        // resolved during Configure; OAuth2 access tokens, if configured
+	oauth     *clientcredentials.Config // resolved during Configure; OAuth2 client credentials, if configured
+	signer    *requestSigner            // resolved during Configure; request signing, if configured
+	auth      *keyAuth                  // resolved during Configure; how the API key is attached
+	schema    string                    // resolved during Configure; file to load resource types from, if set
+	workers   int                       // resolved during Configure; concurrent requests for attributes during discovery
+	maxResp   int64                     // resolved during Configure; maximum size of a response body
+	stats     *latencyStats
+	summary   *applySummary
+
+	discoveryErr error // set by Resources; reported by Configure with fail_on_discovery_error
 }
 
 // kaiakProviderModel maps provider schema data to a Go type.
 type kaiakProviderModel struct {
-	Endpoint types.String `tfsdk:"endpoint"`
-	ApiKey   types.String `tfsdk:"api_key"`
+	Endpoint             types.String `tfsdk:"endpoint"`
+	Port                 types.Int64  `tfsdk:"port"`
+	ApiKey               types.String `tfsdk:"api_key"`
+	ApiKeyFile           types.String `tfsdk:"api_key_file"`
+	AuthHeader           types.String `tfsdk:"auth_header"`
+	AuthScheme           types.String `tfsdk:"auth_scheme"`
+	Profile              types.String `tfsdk:"profile"`
+	StrictConsistency    types.Bool   `tfsdk:"strict_consistency"`
+	CheckReferences      types.Bool   `tfsdk:"check_references"`
+	Endpoints            types.Map    `tfsdk:"endpoints"`
+	ForceDestroy         types.Bool   `tfsdk:"force_destroy"`
+	DeleteMode           types.String `tfsdk:"delete_mode"`
+	AllowRenameInPlace   types.Bool   `tfsdk:"allow_rename_in_place"`
+	UserAgentSuffix      types.String `tfsdk:"user_agent_suffix"`
+	NormalizeLists       types.Map    `tfsdk:"normalize_lists"`
+	ClearAttributes      types.Map    `tfsdk:"clear_attributes"`
+	EmptyAsNull          types.Map    `tfsdk:"empty_as_null"`
+	TransformAttributes  types.Map    `tfsdk:"transform_attributes"`
+	StatusAttributes     types.Map    `tfsdk:"status_attributes"`
+	DefaultAttributes    types.Map    `tfsdk:"default_attributes"`
+	SigningKey           types.String `tfsdk:"signing_key"`
+	SigningAlgorithm     types.String `tfsdk:"signing_algorithm"`
+	SchemaFile           types.String `tfsdk:"schema_file"`
+	DiscoveryWorkers     types.Int64  `tfsdk:"discovery_workers"`
+	Descriptions         types.Map    `tfsdk:"descriptions"`
+	AttributeSeparator   types.String `tfsdk:"attribute_separator"`
+	AttributeGroups      types.Map    `tfsdk:"attribute_groups"`
+	UniqueLists          types.Map    `tfsdk:"unique_lists"`
+	StrictTypes          types.Bool   `tfsdk:"strict_types"`
+	BlockExtras          types.Bool   `tfsdk:"block_extras"`
+	MaxBodySize          types.Int64  `tfsdk:"max_body_size"`
+	MaxResponseSize      types.Int64  `tfsdk:"max_response_size"`
+	WorkspaceAttribute   types.String `tfsdk:"workspace_attribute"`
+	RunIDAttribute       types.String `tfsdk:"run_id_attribute"`
+	ManagedByAttribute   types.String `tfsdk:"managed_by_attribute"`
+	OAuthTokenURL        types.String `tfsdk:"oauth_token_url"`
+	OAuthClientID        types.String `tfsdk:"oauth_client_id"`
+	OAuthClientSecret    types.String `tfsdk:"oauth_client_secret"`
+	OAuthScopes          types.List   `tfsdk:"oauth_scopes"`
+	DeletePollInterval   types.String `tfsdk:"delete_poll_interval"`
+	DeleteTimeout        types.String `tfsdk:"delete_timeout"`
+	TimeFormat           types.String `tfsdk:"time_format"`
+	StateKeyCase         types.String `tfsdk:"state_key_case"`
+	StagedCreate         types.Bool   `tfsdk:"staged_create"`
+	BatchReads           types.Bool   `tfsdk:"batch_reads"`
+	ReadOnly             types.Bool   `tfsdk:"read_only"`
+	FailOnDiscoveryError types.Bool   `tfsdk:"fail_on_discovery_error"`
+	MaxIdleConns         types.Int64  `tfsdk:"max_idle_conns"`
+	MaxIdleConnsPerHost  types.Int64  `tfsdk:"max_idle_conns_per_host"`
+	ForceHTTP2           types.Bool   `tfsdk:"force_http2"`
+	CompressRequests     types.Bool   `tfsdk:"compress_requests"`
+	MaxRetries           types.Int64  `tfsdk:"max_retries"`
+	RetryBudget          types.Int64  `tfsdk:"retry_budget"`
+	RetryBudgetRefill    types.String `tfsdk:"retry_budget_refill"`
+	RetryableStatusCodes types.List   `tfsdk:"retryable_status_codes"`
+	RetryConnErrors      types.Bool   `tfsdk:"retry_connection_errors"`
+}
+
+// providerData is made available to resources and data sources from
+// Configure. It carries the client and any provider-wide settings.
+type providerData struct {
+	client            *httpclient.Client
+	overrides         map[string]*httpclient.Client // resource type → client for an overridden endpoint
+	strictConsistency bool                          // re-read and verify attributes after apply
+	checkReferences   bool                          // warn at plan time when a referenced instance is missing
+	forceDestroy      bool                          // cascade deletes to dependent instances
+	deleteMode        string                        // purge or retain instances on delete
+	renameInPlace     bool                          // rename instances in place when their label changes
+	normalizeLists    map[string][]string           // resource type → list attributes compared as sets
+	clearAttributes   map[string][]string           // resource type → attributes cleared when removed
+	emptyAsNull       map[string][]string           // resource type → string attributes where empty is null
+	statusAttributes  map[string]string             // resource type → instance state key of the status
+	defaultAttributes map[string]map[string]string  // resource type → attribute name → default value
+	deletePoll        time.Duration                 // interval to poll for delete completion, or zero
+	deleteTimeout     time.Duration                 // maximum time to wait for delete completion
+	timeFormat        string                        // representation of time values in state
+	keyCase           string                        // casing of the keys of instance state from the server
+	stagedCreate      bool                          // create instances with their attributes in one request
+	maxBodySize       int64                         // maximum size of the attributes sent in a request, or zero
+	stamps            map[string]string             // attribute name → workspace metadata set on create and update
+	managedBy         string                        // attribute holding the managed-by marker, if set
+	readOnly          bool                          // fail every create, update and delete
+	schema            schemaSettings                // how the schemas of resource types are built
+	stats             *latencyStats
+	summary           *applySummary
+
+	batchers   map[*httpclient.Client]*readBatcher // client → batcher of its reads, when batch reads are enabled
+	transforms map[string]map[string][]string      // resource type → attribute name → transforms
 }
 
 var _ provider.Provider = (*kaiakProvider)(nil)
+var _ provider.ProviderWithValidateConfig = (*kaiakProvider)(nil)
 
 ///////////////////////////////////////////////////////////////////////////////
 // LIFECYCLE
@@ -40,45 +159,423 @@ var _ provider.Provider = (*kaiakProvider)(nil)
 // New returns a provider factory that creates a new provider instance
 // with the given version. It is called by the plugin framework.
 func New(v string) func() provider.Provider {
+	setLogLevel()
 	return func() provider.Provider {
-		return &kaiakProvider{version: v}
+		return &kaiakProvider{version: v, stats: newLatencyStats(), summary: newApplySummary()}
 	}
 }
 
-// resolveEndpoint returns the API endpoint from the environment, falling
-// back to a sensible default.
-func resolveEndpoint() string {
+// resolveEndpoint returns the API endpoint from the profile or else the
+// environment, falling back to a localhost default. The default uses the
+// given port if non-zero, or else KAIAK_PORT, or else port 8084.
+func resolveEndpoint(prof profile, port int64) string {
+	if prof.Endpoint != "" {
+		return prof.Endpoint
+	}
 	if v := os.Getenv("KAIAK_ENDPOINT"); v != "" {
 		return v
 	}
-	return "http://localhost:8084/api"
+	if port == 0 {
+		port = defaultPort
+		if v, err := strconv.ParseInt(os.Getenv("KAIAK_PORT"), 10, 64); err == nil && v > 0 && v <= 65535 {
+			port = v
+		}
+	}
+	return fmt.Sprintf("http://localhost:%d/api", port)
 }
 
-// resolveApiKey returns the API key from the environment, or empty string.
-func resolveApiKey() string {
-	return os.Getenv("KAIAK_API_KEY")
+// checkReachable returns an error if no connection can be made to the host
+// of an endpoint within endpointDialTimeout.
+func checkReachable(ctx context.Context, endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	ctx, cancel := context.WithTimeout(ctx, endpointDialTimeout)
+	defer cancel()
+	conn, err := new(net.Dialer).DialContext(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// resolveEndpoints returns per-resource-type endpoint overrides from the
+// KAIAK_ENDPOINTS environment variable, a comma-separated list of
+// type=url pairs (e.g. "httpserver=http://edge:8084/api").
+func resolveEndpoints() map[string]string {
+	endpoints := map[string]string{}
+	for _, pair := range strings.Split(os.Getenv("KAIAK_ENDPOINTS"), ",") {
+		if resourceType, endpoint, ok := strings.Cut(strings.TrimSpace(pair), "="); ok && resourceType != "" && endpoint != "" {
+			endpoints[resourceType] = endpoint
+		}
+	}
+	return endpoints
+}
+
+// resolveURLTemplates returns per-resource-type templates for the computed
+// url attribute from the KAIAK_URL_TEMPLATES environment variable, a
+// comma-separated list of type=template pairs
+// (e.g. "httpstatic=http://localhost:8080{path}").
+func resolveURLTemplates() map[string]string {
+	templates := map[string]string{}
+	for _, pair := range strings.Split(os.Getenv("KAIAK_URL_TEMPLATES"), ",") {
+		if resourceType, template, ok := strings.Cut(strings.TrimSpace(pair), "="); ok && resourceType != "" && template != "" {
+			templates[resourceType] = template
+		}
+	}
+	return templates
+}
+
+// resolveMap returns the value of a map provider setting, or when it is not
+// set, the value of the environment variable as a JSON object, unless env is
+// empty. An invalid environment variable is logged and ignored.
+func resolveMap[T any](ctx context.Context, setting types.Map, env string, diags *diag.Diagnostics) map[string]T {
+	var result map[string]T
+	if !setting.IsNull() && !setting.IsUnknown() {
+		diags.Append(setting.ElementsAs(ctx, &result, false)...)
+		return result
+	}
+	if v := os.Getenv(env); v != "" {
+		if err := json.Unmarshal([]byte(v), &result); err != nil {
+			logWarn(ctx, fmt.Sprintf("Invalid %s: ignoring it", env), map[string]interface{}{
+				"error": err.Error(),
+			})
+			return nil
+		}
+	}
+	return result
 }
 
-// clientOpts returns the common client options for the given API key,
-// including request tracing when KAIAK_TRACE is set.
-func clientOpts(apiKey string) []client.ClientOpt {
-	var opts []client.ClientOpt
-	if apiKey != "" {
+// resolveSchemaSettings returns the settings for building the schemas of
+// resource types from the environment variables.
+func resolveSchemaSettings(ctx context.Context) schemaSettings {
+	var diags diag.Diagnostics // no settings are configured, so there are none
+	return configSchemaSettings(ctx, kaiakProviderModel{}, &diags)
+}
+
+// configSchemaSettings returns the settings for building the schemas of
+// resource types, as set in the provider block: config values > environment
+// variables.
+func configSchemaSettings(ctx context.Context, config kaiakProviderModel, diags *diag.Diagnostics) schemaSettings {
+	settings := schemaSettings{
+		descriptions: resolveMap[string](ctx, config.Descriptions, "KAIAK_DESCRIPTIONS", diags),
+		groups:       resolveMap[[]string](ctx, config.AttributeGroups, "KAIAK_ATTRIBUTE_GROUPS", diags),
+		unique:       resolveMap[[]string](ctx, config.UniqueLists, "KAIAK_UNIQUE_LISTS", diags),
+		separator:    config.AttributeSeparator.ValueString(),
+		strictTypes:  config.StrictTypes.ValueBool(),
+		blockExtras:  config.BlockExtras.ValueBool(),
+	}
+	if config.AttributeSeparator.IsNull() {
+		settings.separator = os.Getenv("KAIAK_ATTRIBUTE_SEPARATOR")
+	}
+	if config.StrictTypes.IsNull() {
+		settings.strictTypes = os.Getenv("KAIAK_STRICT_TYPES") != ""
+	}
+	if config.BlockExtras.IsNull() {
+		settings.blockExtras = os.Getenv("KAIAK_BLOCK_EXTRAS") != ""
+	}
+	return settings
+}
+
+// checkSchemaSettings adds an error for each schema setting in the provider
+// block which differs from the settings the schemas were built with. Schemas
+// are built before the provider block is read, from the environment, so the
+// provider block cannot change them.
+func checkSchemaSettings(ctx context.Context, config kaiakProviderModel, built schemaSettings, diags *diag.Diagnostics) {
+	configured := configSchemaSettings(ctx, config, diags)
+	for _, s := range []struct {
+		name, env string
+		equal     bool
+	}{
+		{"descriptions", "KAIAK_DESCRIPTIONS", maps.Equal(configured.descriptions, built.descriptions)},
+		{"attribute_groups", "KAIAK_ATTRIBUTE_GROUPS", maps.EqualFunc(configured.groups, built.groups, slices.Equal[[]string])},
+		{"unique_lists", "KAIAK_UNIQUE_LISTS", maps.EqualFunc(configured.unique, built.unique, slices.Equal[[]string])},
+		{"attribute_separator", "KAIAK_ATTRIBUTE_SEPARATOR", configured.separator == built.separator},
+		{"strict_types", "KAIAK_STRICT_TYPES", configured.strictTypes == built.strictTypes},
+		{"block_extras", "KAIAK_BLOCK_EXTRAS", configured.blockExtras == built.blockExtras},
+	} {
+		if !s.equal {
+			diags.AddAttributeError(path.Root(s.name), "Schema setting differs from the environment",
+				fmt.Sprintf("The schemas of resource types are built from the %s environment variable before the "+
+					"provider block is read, so %q cannot change them. Set %s to the same value, or remove %q.",
+					s.env, s.name, s.env, s.name))
+		}
+	}
+}
+
+// resolveDiscoveryWorkers returns the number of concurrent requests for the
+// attributes of resource types during discovery from KAIAK_DISCOVERY_WORKERS,
+// or else the default.
+func resolveDiscoveryWorkers() int {
+	if v, err := strconv.Atoi(os.Getenv("KAIAK_DISCOVERY_WORKERS")); err == nil && v > 0 {
+		return v
+	}
+	return defaultDiscoveryWorkers
+}
+
+// defaultPort is the port of the default endpoint.
+const defaultPort = 8084
+
+// endpointDialTimeout is the maximum time to wait for a connection when
+// checking that the default endpoint can be reached.
+const endpointDialTimeout = 2 * time.Second
+
+// defaultDiscoveryWorkers is the default number of concurrent requests for
+// the attributes of resource types during discovery.
+const defaultDiscoveryWorkers = 4
+
+// defaultDeleteTimeout is the maximum time to wait for an instance to be
+// destroyed, when polling for delete completion.
+const defaultDeleteTimeout = 5 * time.Minute
+
+// resolveApiKey returns the API key from the profile or else the
+// environment: the profile's api_key, or else the contents of its
+// api_key_file, or else KAIAK_API_KEY, or else the contents of the file
+// named by KAIAK_API_KEY_FILE, or empty string.
+func resolveApiKey(prof profile) (string, error) {
+	if prof.APIKey != "" {
+		return prof.APIKey, nil
+	}
+	if prof.APIKeyFile != "" {
+		return readApiKeyFile(prof.APIKeyFile)
+	}
+	if v := os.Getenv("KAIAK_API_KEY"); v != "" {
+		return v, nil
+	}
+	if v := os.Getenv("KAIAK_API_KEY_FILE"); v != "" {
+		return readApiKeyFile(v)
+	}
+	return "", nil
+}
+
+// resolveOAuth returns the OAuth2 client credentials from the environment,
+// or nil if KAIAK_OAUTH_TOKEN_URL is not set. KAIAK_OAUTH_SCOPES is a
+// comma-separated list.
+func resolveOAuth() *clientcredentials.Config {
+	tokenURL := os.Getenv("KAIAK_OAUTH_TOKEN_URL")
+	if tokenURL == "" {
+		return nil
+	}
+	var scopes []string
+	for _, scope := range strings.Split(os.Getenv("KAIAK_OAUTH_SCOPES"), ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return &clientcredentials.Config{
+		TokenURL:     tokenURL,
+		ClientID:     os.Getenv("KAIAK_OAUTH_CLIENT_ID"),
+		ClientSecret: os.Getenv("KAIAK_OAUTH_CLIENT_SECRET"),
+		Scopes:       scopes,
+	}
+}
+
+// readApiKeyFile reads an API key from a file, such as a mounted secret,
+// trimming any trailing newlines.
+func readApiKeyFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// userAgent returns the User-Agent sent with every request, identifying the
+// provider and Terraform versions, followed by an optional suffix.
+func userAgent(version, terraformVersion, suffix string) string {
+	if version == "" {
+		version = "dev"
+	}
+	ua := "terraform-provider-kaiak/" + version
+	if terraformVersion != "" {
+		ua += " terraform/" + terraformVersion
+	}
+	if suffix = strings.TrimSpace(suffix); suffix != "" {
+		ua += " " + suffix
+	}
+	return ua
+}
+
+// clientOpts returns the common client options for the given API key and
+// User-Agent, including request tracing when KAIAK_TRACE is set. The API key
+// is attached as auth describes. When an OAuth2 token source is given, it is
+// used instead of the API key. When a signer is given, every request is
+// signed. Requests made within a CRUD operation carry its correlation id, and
+// response bodies are limited to maxResponseSize bytes.
+func clientOpts(apiKey string, auth keyAuth, ua string, tokens oauth2.TokenSource, signer *requestSigner, maxResponseSize int64) []client.ClientOpt {
+	opts := []client.ClientOpt{client.OptUserAgent(ua)}
+	if tokens != nil {
+		opts = append(opts, optTokenSource(tokens))
+	} else if apiKey != "" && auth != defaultKeyAuth {
+		opts = append(opts, optKeyAuth(auth, apiKey))
+	} else if apiKey != "" {
 		opts = append(opts, client.OptReqToken(client.Token{
 			Scheme: client.Bearer,
 			Value:  apiKey,
 		}))
 	}
+	if signer != nil {
+		opts = append(opts, optSigner(signer))
+	}
 	if os.Getenv("KAIAK_TRACE") != "" {
 		verbose := os.Getenv("KAIAK_TRACE") == "verbose"
 		opts = append(opts, client.OptTrace(os.Stderr, verbose))
 	}
-	return opts
+	return append(opts, optRequestID(), optMaxResponseSize(maxResponseSize))
+}
+
+// optTokenSource sets the bearer token of every request from an OAuth2 token
+// source, which obtains a new access token whenever the current one expires.
+func optTokenSource(tokens oauth2.TokenSource) client.ClientOpt {
+	return func(c *client.Client) error {
+		c.Transport = &oauth2.Transport{Source: tokens, Base: c.Transport}
+		return nil
+	}
+}
+
+// optTransport replaces the default transport with one using the given
+// connection settings, leaving the defaults for any which are null. It must
+// come before any option which wraps the transport.
+func optTransport(maxIdle, maxIdlePerHost types.Int64, forceHTTP2 types.Bool) client.ClientOpt {
+	return func(c *client.Client) error {
+		if maxIdle.IsNull() && maxIdlePerHost.IsNull() && !forceHTTP2.ValueBool() {
+			return nil
+		}
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		if !maxIdle.IsNull() {
+			t.MaxIdleConns = int(maxIdle.ValueInt64())
+		}
+		if !maxIdlePerHost.IsNull() {
+			t.MaxIdleConnsPerHost = int(maxIdlePerHost.ValueInt64())
+		}
+		if forceHTTP2.ValueBool() {
+			t.Protocols = new(http.Protocols)
+			t.Protocols.SetHTTP2(true)
+			t.Protocols.SetUnencryptedHTTP2(true)
+		}
+		c.Transport = t
+		return nil
+	}
+}
+
+// discoverEndpoint returns the resource types discovered from the server at
+// the given endpoint, fetching the attributes of up to workers resource
+// types at a time when the server lists them without. Errors are logged and
+// returned, and the resource types affected are not returned.
+func discoverEndpoint(ctx context.Context, endpoint string, opts []client.ClientOpt, workers int) ([]resourceMeta, error) {
+	cl, err := httpclient.New(endpoint, opts...)
+	if err != nil {
+		logError(ctx, "Failed to create Kaiak client. No resources will be available from this endpoint.", map[string]interface{}{
+			"endpoint": endpoint,
+			"error":    err.Error(),
+		})
+		return nil, fmt.Errorf("%s: %w", endpoint, err)
+	}
+
+	result, err := discoverResources(ctx, cl, schema.ListResourcesRequest{})
+	if err != nil {
+		logError(ctx, "Failed to discover resources from Kaiak server. No resources will be available from this endpoint.", map[string]interface{}{
+			"endpoint": endpoint,
+			"error":    err.Error(),
+		})
+		return nil, fmt.Errorf("%s: %w", endpoint, err)
+	}
+
+	// Resource types listed without their attributes are fetched one by one,
+	// and any which fail are omitted rather than failing discovery
+	metas, errs := discoverAttributes(ctx, endpoint, opts, result.Resources, workers)
+	var failed []error
+	for name, err := range errs {
+		logError(ctx, "Failed to discover resource attributes from Kaiak server. The resource type will not be available.", map[string]interface{}{
+			"endpoint": endpoint,
+			"resource": name,
+			"error":    err.Error(),
+		})
+		failed = append(failed, fmt.Errorf("%s: resource type %q: %w", endpoint, name, err))
+	}
+	logDebug(ctx, "Discovered resources from Kaiak server", map[string]interface{}{
+		"endpoint":  endpoint,
+		"resources": len(metas),
+	})
+	return metas, errors.Join(failed...)
+}
+
+// managedByMarker is the value of the managed_by_attribute stamped on
+// instances.
+const managedByMarker = "terraform"
+
+// workspaceStamps returns the workspace metadata to set on each instance
+// created or updated, keyed by the attribute names given. Metadata which is
+// not available from the environment is omitted.
+func workspaceStamps(ctx context.Context, workspaceAttr, runIDAttr string) map[string]string {
+	stamps := map[string]string{}
+	for _, stamp := range []struct{ attr, env string }{
+		{workspaceAttr, "TF_WORKSPACE"},
+		{runIDAttr, "TFC_RUN_ID"},
+	} {
+		if stamp.attr == "" {
+			continue
+		}
+		if value := os.Getenv(stamp.env); value != "" {
+			stamps[stamp.attr] = value
+		} else {
+			logWarn(ctx, "Workspace metadata is not available from the environment", map[string]interface{}{
+				"attribute": stamp.attr,
+				"variable":  stamp.env,
+			})
+		}
+	}
+	return stamps
+}
+
+// parsePositiveDuration parses a duration such as "2s", which must be
+// positive.
+func parsePositiveDuration(s string) (time.Duration, error) {
+	v, err := time.ParseDuration(s)
+	if err == nil && v <= 0 {
+		err = fmt.Errorf("must be positive, got %q", s)
+	}
+	return v, err
+}
+
+// resourceFactories returns a factory for each resource type, with the
+// schema settings applied. A URL template from the environment replaces any
+// the server provides.
+func resourceFactories(metas []resourceMeta, settings schemaSettings) []func() resource.Resource {
+	templates := resolveURLTemplates()
+	factories := make([]func() resource.Resource, 0, len(metas))
+	for _, r := range metas {
+		meta := settings.apply(r)
+		if template, ok := templates[meta.Name]; ok {
+			meta.URLTemplate = template
+		}
+		factories = append(factories, func() resource.Resource {
+			return newDynamicResource(meta)
+		})
+	}
+	return factories
 }
 
 ///////////////////////////////////////////////////////////////////////////////
 // PROVIDER INTERFACE
 
+// clientFor returns the client for a resource type, which is the client
+// for its endpoint override when there is one.
+func (d *providerData) clientFor(resourceType string) *httpclient.Client {
+	if cl, ok := d.overrides[resourceType]; ok {
+		return cl
+	}
+	return d.client
+}
+
 func (p *kaiakProvider) Metadata(_ context.Context, _ provider.MetadataRequest, resp *provider.MetadataResponse) {
 	resp.TypeName = "kaiak"
 	resp.Version = p.version
@@ -93,12 +590,367 @@ func (p *kaiakProvider) Schema(_ context.Context, _ provider.SchemaRequest, resp
 					"Can also be set via the KAIAK_ENDPOINT environment variable.",
 				Optional: true,
 			},
+			"port": tfschema.Int64Attribute{
+				Description: "Port of the default endpoint, http://localhost:<port>/api, used when no endpoint " +
+					"is set. Defaults to 8084. Can also be set via the KAIAK_PORT environment variable.",
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.Between(1, 65535),
+				},
+			},
+			"endpoints": tfschema.MapAttribute{
+				Description: "Per-resource-type endpoint overrides, mapping a resource type name to the base URL " +
+					"of the Kaiak server which hosts it. Types without an override use \"endpoint\". " +
+					"Can also be set via the KAIAK_ENDPOINTS environment variable (type=url,...).",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
 			"api_key": tfschema.StringAttribute{
 				Description: "API key (bearer token) for authenticating with the Kaiak server. " +
 					"Can also be set via the KAIAK_API_KEY environment variable.",
 				Optional:  true,
 				Sensitive: true,
 			},
+			"api_key_file": tfschema.StringAttribute{
+				Description: "Path to a file containing the API key, such as a mounted secret. Trailing newlines " +
+					"are trimmed. Conflicts with api_key. Can also be set via the KAIAK_API_KEY_FILE environment variable.",
+				Optional: true,
+			},
+			"auth_header": tfschema.StringAttribute{
+				Description: "Request header which carries the API key. Defaults to \"Authorization\". Set it for " +
+					"servers which expect the key in a custom header, such as \"X-API-Key\". Can also be set via " +
+					"the KAIAK_AUTH_HEADER environment variable.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"auth_scheme": tfschema.StringAttribute{
+				Description: "Scheme which precedes the API key in the auth_header. Defaults to \"Bearer\". Set it " +
+					"empty to send the key alone. Can also be set via the KAIAK_AUTH_SCHEME environment variable.",
+				Optional: true,
+			},
+			"profile": tfschema.StringAttribute{
+				Description: "Name of a profile in the config file (~/.kaiak/config, or KAIAK_CONFIG_FILE) to read the " +
+					"endpoint and API key from. Explicit endpoint and API key settings take precedence over the profile, " +
+					"and the profile over environment variables. Can also be set via the KAIAK_PROFILE environment variable.",
+				Optional: true,
+			},
+			"strict_consistency": tfschema.BoolAttribute{
+				Description: "When true, re-read each instance after create or update and report any " +
+					"configured attribute whose server value differs from the value sent. Defaults to false.",
+				Optional: true,
+			},
+			"check_references": tfschema.BoolAttribute{
+				Description: "When true, look up referenced instances at plan time and warn when a " +
+					"reference does not exist on the server. Defaults to false.",
+				Optional: true,
+			},
+			"user_agent_suffix": tfschema.StringAttribute{
+				Description: "Text appended to the User-Agent header sent with every request, such as a team " +
+					"or pipeline identifier, to correlate changes in server logs.",
+				Optional: true,
+			},
+			"force_destroy": tfschema.BoolAttribute{
+				Description: "When true, destroying an instance also destroys every instance which references it, " +
+					"in dependency order. Those dependents may not be managed by Terraform. Defaults to false.",
+				Optional: true,
+			},
+			"delete_mode": tfschema.StringAttribute{
+				Description: "How the server deletes an instance when it is destroyed: \"purge\" (the default) " +
+					"removes it outright, and \"retain\" keeps it on the server for recovery. The server must " +
+					"support retaining instances.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(deleteModePurge, deleteModeRetain),
+				},
+			},
+			"allow_rename_in_place": tfschema.BoolAttribute{
+				Description: "When true, changing the label of an instance renames it in place on the server, " +
+					"rather than replacing it. The server must support renaming instances. Defaults to false.",
+				Optional: true,
+			},
+			"normalize_lists": tfschema.MapAttribute{
+				Description: "Map of resource type to the names of list attributes which the server treats as " +
+					"sets. The order and duplicates of these lists are kept from configuration when the server " +
+					"holds the same elements, avoiding perpetual diffs when the server sorts or deduplicates them.",
+				ElementType: types.ListType{ElemType: types.StringType},
+				Optional:    true,
+			},
+			"status_attributes": tfschema.MapAttribute{
+				Description: "Map of resource type to the key of the instance state from the server which holds " +
+					"its runtime status (e.g. \"state\"), from which the computed status attribute is set. Resource " +
+					"types not in the map take the status which the server reports alongside the instance, if any.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"default_attributes": tfschema.MapAttribute{
+				Description: "Map of resource type to a map of attribute name to a default value, which is used " +
+					"when the attribute is not set in configuration, and takes precedence over any server default. " +
+					"Values are strings, with lists and maps given as JSON, for example with jsonencode().",
+				ElementType: types.MapType{ElemType: types.StringType},
+				Optional:    true,
+			},
+			"clear_attributes": tfschema.MapAttribute{
+				Description: "Map of resource type to the names of optional attributes which are cleared on the " +
+					"server when removed from configuration, by sending an explicit null, rather than keeping " +
+					"their current value.",
+				ElementType: types.ListType{ElemType: types.StringType},
+				Optional:    true,
+			},
+			"empty_as_null": tfschema.MapAttribute{
+				Description: "Map of resource type to the names of string attributes for which an empty string is " +
+					"the same as no value. An empty string is sent to the server as null, and a value the server " +
+					"reports as empty or null is kept in state as configured, avoiding a perpetual diff.",
+				ElementType: types.ListType{ElemType: types.StringType},
+				Optional:    true,
+			},
+			"transform_attributes": tfschema.MapAttribute{
+				Description: "Map of resource type to a map of string attribute name to a list of transforms applied " +
+					"in order to its value sent to the server, and reversed where possible on read: \"base64encode\", " +
+					"\"base64decode\", \"trimspace\", \"tolower\" or \"toupper\".",
+				ElementType: types.MapType{ElemType: types.ListType{ElemType: types.StringType}},
+				Optional:    true,
+			},
+			"delete_poll_interval": tfschema.StringAttribute{
+				Description: "When set, wait after destroying an instance until the server no longer reports it, " +
+					"checking at this interval (e.g. \"2s\"). For resources which tear down asynchronously.",
+				Optional: true,
+			},
+			"delete_timeout": tfschema.StringAttribute{
+				Description: "Maximum time to wait for an instance to be destroyed when delete_poll_interval is set " +
+					"(e.g. \"10m\"). Defaults to 5m.",
+				Optional: true,
+			},
+			"staged_create": tfschema.BoolAttribute{
+				Description: "When true, each instance is created with its attributes applied in a single request, " +
+					"rather than a create followed by an update. Requires server support. Defaults to false.",
+				Optional: true,
+			},
+			"batch_reads": tfschema.BoolAttribute{
+				Description: "When true, reads of instances made at about the same time, such as during a refresh, " +
+					"are combined into a single request to the server's batch endpoint. Servers without one are read " +
+					"one instance at a time. Defaults to false.",
+				Optional: true,
+			},
+			"fail_on_discovery_error": tfschema.BoolAttribute{
+				Description: "When true, a failure to discover resource types from the server is reported as an " +
+					"error when the provider is configured, rather than only logged. Defaults to false.",
+				Optional: true,
+			},
+			"read_only": tfschema.BoolAttribute{
+				Description: "When true, every create, update and delete fails without contacting the server, " +
+					"while refreshes and data sources work as usual. Defaults to false.",
+				Optional: true,
+			},
+			"max_body_size": tfschema.Int64Attribute{
+				Description: "Maximum size in bytes of the attributes sent to the server in a single request. " +
+					"A create or update which exceeds it fails before any request is made. Defaults to no limit.",
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"max_response_size": tfschema.Int64Attribute{
+				Description: "Maximum size in bytes of a response read from the server. A request whose response " +
+					"exceeds it fails, rather than the whole response being held in memory. Defaults to 64 MiB.",
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"workspace_attribute": tfschema.StringAttribute{
+				Description: "Name of a string attribute which is set to the Terraform workspace, from the " +
+					"TF_WORKSPACE environment variable, on every instance created or updated, unless set in " +
+					"configuration. Resource types without the attribute are not stamped.",
+				Optional: true,
+			},
+			"run_id_attribute": tfschema.StringAttribute{
+				Description: "Name of a string attribute which is set to the run ID, from the TFC_RUN_ID " +
+					"environment variable, on every instance created or updated, unless set in configuration. " +
+					"Resource types without the attribute are not stamped.",
+				Optional: true,
+			},
+			"managed_by_attribute": tfschema.StringAttribute{
+				Description: "Name of a string attribute which is set to \"terraform\" on every instance created or " +
+					"updated, unless set in configuration. A refresh warns when the server no longer reports the " +
+					"marker, a sign that the instance was modified outside Terraform.",
+				Optional: true,
+			},
+			"max_idle_conns": tfschema.Int64Attribute{
+				Description: "Advanced: maximum number of idle (keep-alive) connections across all servers. " +
+					"Zero means no limit. Defaults to 100.",
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"max_idle_conns_per_host": tfschema.Int64Attribute{
+				Description: "Advanced: maximum number of idle (keep-alive) connections to each server. " +
+					"Defaults to 2.",
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"force_http2": tfschema.BoolAttribute{
+				Description: "Advanced: when true, only HTTP/2 is used, including over unencrypted connections, " +
+					"so that requests share a single connection to each server. The server must support HTTP/2.",
+				Optional: true,
+			},
+			"compress_requests": tfschema.BoolAttribute{
+				Description: "Advanced: when true, request bodies of 1 KiB or more are compressed with gzip. " +
+					"When the server rejects a compressed body, requests are sent uncompressed instead.",
+				Optional: true,
+			},
+			"max_retries": tfschema.Int64Attribute{
+				Description: "Maximum number of times a request is retried after a server (5xx) or network error, " +
+					"with exponential backoff. Defaults to 0, which disables retries.",
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"retry_budget": tfschema.Int64Attribute{
+				Description: "Maximum number of retries shared across all requests, after which requests fail " +
+					"without retrying until the budget is refilled. Defaults to 10.",
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(0),
+				},
+			},
+			"retry_budget_refill": tfschema.StringAttribute{
+				Description: "Interval at which one retry is returned to the retry budget (e.g. \"30s\"). Defaults to 30s.",
+				Optional:    true,
+			},
+			"retryable_status_codes": tfschema.ListAttribute{
+				Description: "HTTP status codes which are retried in addition to server (5xx) errors, such as 409. " +
+					"Retrying a request which the server may have partly applied can be unsafe.",
+				ElementType: types.Int64Type,
+				Optional:    true,
+				Validators: []validator.List{
+					listvalidator.ValueInt64sAre(int64validator.Between(400, 499)),
+				},
+			},
+			"retry_connection_errors": tfschema.BoolAttribute{
+				Description: "Whether a request which fails without a response, such as when the connection is refused " +
+					"because the server is down, is retried. Set to false to fail fast, for example in CI, while " +
+					"still retrying server (5xx) errors. Defaults to true.",
+				Optional: true,
+			},
+			"time_format": tfschema.StringAttribute{
+				Description: "Representation of time attributes in state: \"rfc3339\" (the default) or \"unix\" " +
+					"(seconds since the epoch). The server may report times in either form.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(timeFormatRFC3339, timeFormatUnix),
+				},
+			},
+			"state_key_case": tfschema.StringAttribute{
+				Description: "Casing of the keys of instance state returned by the server, when it differs from " +
+					"the attribute names in its resource schema: \"as-is\" (the default), \"snake\" or \"camel\".",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(keyCaseAsIs, keyCaseSnake, keyCaseCamel),
+				},
+			},
+			"schema_file": tfschema.StringAttribute{
+				Description: "Path to a JSON file containing the resource type metadata, in the same form as the " +
+					"server's resource list. When set, resource types are loaded from this file rather than " +
+					"discovered from the server. Can also be set via the KAIAK_SCHEMA_FILE environment variable.",
+				Optional: true,
+			},
+			"discovery_workers": tfschema.Int64Attribute{
+				Description: "Maximum number of concurrent requests for the attributes of resource types during " +
+					"discovery, for servers which list resource types without their attributes. Defaults to 4. " +
+					"Can also be set via the KAIAK_DISCOVERY_WORKERS environment variable.",
+				Optional: true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"descriptions": tfschema.MapAttribute{
+				Description: "Descriptions of resource types, mapping a resource type name to the description of " +
+					"its schema, which replaces the one from the server. Schemas are built from the KAIAK_DESCRIPTIONS " +
+					"environment variable, as a JSON object, before the provider block is read, so when set this " +
+					"must match it.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
+			"attribute_separator": tfschema.StringAttribute{
+				Description: "Separator of the blocks and field in the attribute names of the server, such as " +
+					"\"/\" for \"tls/cert\". Defaults to \".\". Schemas are built from the KAIAK_ATTRIBUTE_SEPARATOR " +
+					"environment variable before the provider block is read, so when set this must match it.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"attribute_groups": tfschema.MapAttribute{
+				Description: "Top-level attributes to group into blocks, mapping a resource type name to prefixes " +
+					"of attribute names, such as \"log\" for \"log_level\" in block \"log\". Schemas are built from " +
+					"the KAIAK_ATTRIBUTE_GROUPS environment variable, as a JSON object, before the provider block " +
+					"is read, so when set this must match it.",
+				ElementType: types.ListType{ElemType: types.StringType},
+				Optional:    true,
+			},
+			"unique_lists": tfschema.MapAttribute{
+				Description: "List attributes whose elements must be unique, mapping a resource type name to " +
+					"attribute names, for servers which do not mark them. Schemas are built from the " +
+					"KAIAK_UNIQUE_LISTS environment variable, as a JSON object, before the provider block is " +
+					"read, so when set this must match it.",
+				ElementType: types.ListType{ElemType: types.StringType},
+				Optional:    true,
+			},
+			"strict_types": tfschema.BoolAttribute{
+				Description: "When true, a resource type with an attribute of a type this provider does not " +
+					"support fails with an error, rather than representing the attribute as a string. Defaults " +
+					"to false. Schemas are built from the KAIAK_STRICT_TYPES environment variable before the provider " +
+					"block is read, so when set this must match it.",
+				Optional: true,
+			},
+			"block_extras": tfschema.BoolAttribute{
+				Description: "When true, each block has a computed \"extra\" map of the members returned by the " +
+					"server which are not in the schema. Defaults to false. Schemas are built from the " +
+					"KAIAK_BLOCK_EXTRAS environment variable before the provider block is read, so when set " +
+					"this must match it.",
+				Optional: true,
+			},
+			"signing_key": tfschema.StringAttribute{
+				Description: "Key used to sign every request with an HMAC, for API gateways which require signed " +
+					"requests. Can also be set via the KAIAK_SIGNING_KEY environment variable.",
+				Optional:  true,
+				Sensitive: true,
+			},
+			"signing_algorithm": tfschema.StringAttribute{
+				Description: "Algorithm used to sign requests: \"hmac-sha256\" (the default) or \"hmac-sha512\". " +
+					"Can also be set via the KAIAK_SIGNING_ALGORITHM environment variable.",
+				Optional: true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(signingHMACSHA256, signingHMACSHA512),
+				},
+			},
+			"oauth_token_url": tfschema.StringAttribute{
+				Description: "Token URL of an OAuth2 provider. When set, an access token is obtained with the " +
+					"client credentials grant and used instead of api_key. Can also be set via the " +
+					"KAIAK_OAUTH_TOKEN_URL environment variable.",
+				Optional: true,
+			},
+			"oauth_client_id": tfschema.StringAttribute{
+				Description: "OAuth2 client ID. Can also be set via the KAIAK_OAUTH_CLIENT_ID environment variable.",
+				Optional:    true,
+			},
+			"oauth_client_secret": tfschema.StringAttribute{
+				Description: "OAuth2 client secret. Can also be set via the KAIAK_OAUTH_CLIENT_SECRET environment variable.",
+				Optional:    true,
+				Sensitive:   true,
+			},
+			"oauth_scopes": tfschema.ListAttribute{
+				Description: "OAuth2 scopes to request. Can also be set via the KAIAK_OAUTH_SCOPES environment " +
+					"variable as a comma-separated list.",
+				ElementType: types.StringType,
+				Optional:    true,
+			},
 		},
 	}
 }
@@ -110,6 +962,16 @@ func (p *kaiakProvider) Configure(ctx context.Context, req provider.ConfigureReq
 		return
 	}
 
+	// Resource types are discovered before the provider block is read, so
+	// a discovery failure can only be reported now
+	if config.FailOnDiscoveryError.ValueBool() && p.discoveryErr != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("fail_on_discovery_error"), "Resource discovery failed",
+			fmt.Sprintf("Resource types could not be discovered, so some or all kaiak resources are unavailable:\n\n%s\n\n"+
+				"Discovery uses the KAIAK_* environment variables, as it runs before the provider block is read. "+
+				"Check that the server is reachable with them, or set schema_file to plan without a server.", p.discoveryErr))
+		return
+	}
+
 	// Reject unknown values — they can cause silent fallback to env/defaults
 	if config.Endpoint.IsUnknown() {
 		resp.Diagnostics.AddError("Unknown endpoint",
@@ -121,84 +983,593 @@ func (p *kaiakProvider) Configure(ctx context.Context, req provider.ConfigureReq
 			"The \"api_key\" attribute is not yet known. Set it to a concrete value or use the KAIAK_API_KEY environment variable.")
 		return
 	}
+	if config.ApiKeyFile.IsUnknown() {
+		resp.Diagnostics.AddError("Unknown api_key_file",
+			"The \"api_key_file\" attribute is not yet known. Set it to a concrete value or use the KAIAK_API_KEY_FILE environment variable.")
+		return
+	}
+
+	if config.AuthHeader.IsUnknown() || config.AuthScheme.IsUnknown() {
+		resp.Diagnostics.AddError("Unknown auth settings",
+			"The \"auth_*\" attributes are not yet known. Set them to concrete values or use the KAIAK_AUTH_* environment variables.")
+		return
+	}
+
+	if config.Profile.IsUnknown() {
+		resp.Diagnostics.AddError("Unknown profile",
+			"The \"profile\" attribute is not yet known. Set it to a concrete value or use the KAIAK_PROFILE environment variable.")
+		return
+	}
+
+	if config.Endpoints.IsUnknown() {
+		resp.Diagnostics.AddError("Unknown endpoints",
+			"The \"endpoints\" attribute is not yet known. Set it to a concrete value or use the KAIAK_ENDPOINTS environment variable.")
+		return
+	}
+
+	if config.OAuthTokenURL.IsUnknown() || config.OAuthClientID.IsUnknown() ||
+		config.OAuthClientSecret.IsUnknown() || config.OAuthScopes.IsUnknown() {
+		resp.Diagnostics.AddError("Unknown OAuth2 settings",
+			"The \"oauth_*\" attributes are not yet known. Set them to concrete values or use the KAIAK_OAUTH_* environment variables.")
+		return
+	}
+
+	if config.SchemaFile.IsUnknown() {
+		resp.Diagnostics.AddError("Unknown schema_file",
+			"The \"schema_file\" attribute is not yet known. Set it to a concrete value or use the KAIAK_SCHEMA_FILE environment variable.")
+		return
+	}
+
+	if config.SigningKey.IsUnknown() || config.SigningAlgorithm.IsUnknown() {
+		resp.Diagnostics.AddError("Unknown signing settings",
+			"The \"signing_*\" attributes are not yet known. Set them to concrete values or use the KAIAK_SIGNING_* environment variables.")
+		return
+	}
+
+	// Resolve the profile: config value > environment variable
+	prof, err := resolveProfile(config.Profile.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("profile"), "Failed to read profile", err.Error())
+		return
+	}
 
-	// Resolve endpoint: config value > environment variable > default
+	// Resolve the schema file: config value > environment variable
+	schemaFile := config.SchemaFile.ValueString()
+	if schemaFile == "" {
+		schemaFile = os.Getenv("KAIAK_SCHEMA_FILE")
+	}
+	if schemaFile != "" {
+		if _, err := loadSchemaFile(schemaFile); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("schema_file"), "Failed to read schema file", err.Error())
+			return
+		}
+	}
+
+	// Resolve endpoint: config value > profile > environment variable >
+	// default, where the default uses the configured port
 	endpoint := config.Endpoint.ValueString()
 	if endpoint == "" {
-		endpoint = resolveEndpoint()
+		endpoint = resolveEndpoint(prof, config.Port.ValueInt64())
+
+		// A default endpoint which cannot be reached is reported now, rather
+		// than as a connection error from the first request. With a schema
+		// file the provider can validate and plan offline, so it is a warning.
+		if prof.Endpoint == "" && os.Getenv("KAIAK_ENDPOINT") == "" {
+			if err := checkReachable(ctx, endpoint); err != nil {
+				detail := fmt.Sprintf("No endpoint is set in the provider block, the profile or the KAIAK_ENDPOINT environment "+
+					"variable, and the default endpoint %s cannot be reached: %s. Set \"endpoint\" in the provider "+
+					"block, or KAIAK_ENDPOINT, to the URL of the Kaiak server API (e.g. \"http://kaiak.example.com:8084/api\").",
+					endpoint, err)
+				if schemaFile != "" {
+					resp.Diagnostics.AddAttributeWarning(path.Root("endpoint"), "No Kaiak endpoint configured", detail)
+				} else {
+					resp.Diagnostics.AddAttributeError(path.Root("endpoint"), "No Kaiak endpoint configured", detail)
+					return
+				}
+			}
+		}
 	}
 
-	// Resolve API key: config value > environment variable
+	// Resolve API key: config value > config file > profile > environment
+	// variable
 	apiKey := config.ApiKey.ValueString()
+	if apiKey == "" && config.ApiKeyFile.ValueString() != "" {
+		v, err := readApiKeyFile(config.ApiKeyFile.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("api_key_file"), "Failed to read API key file", err.Error())
+			return
+		}
+		apiKey = v
+	}
 	if apiKey == "" {
-		apiKey = resolveApiKey()
+		v, err := resolveApiKey(prof)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to read API key file",
+				fmt.Sprintf("KAIAK_API_KEY_FILE or the profile's api_key_file: %s", err))
+			return
+		}
+		apiKey = v
+	}
+
+	// Resolve how the API key is attached: config values > environment variables
+	auth := resolveKeyAuth()
+	if !config.AuthHeader.IsNull() {
+		auth.header = config.AuthHeader.ValueString()
+	}
+	if !config.AuthScheme.IsNull() {
+		auth.scheme = config.AuthScheme.ValueString()
+	}
+
+	// Resolve endpoint overrides: config value > environment variable
+	endpoints := resolveEndpoints()
+	if !config.Endpoints.IsNull() {
+		endpoints = map[string]string{}
+		resp.Diagnostics.Append(config.Endpoints.ElementsAs(ctx, &endpoints, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	// Resolve OAuth2 client credentials: config values > environment variables
+	oauth := resolveOAuth()
+	if !config.OAuthTokenURL.IsNull() {
+		oauth = &clientcredentials.Config{
+			TokenURL:     config.OAuthTokenURL.ValueString(),
+			ClientID:     config.OAuthClientID.ValueString(),
+			ClientSecret: config.OAuthClientSecret.ValueString(),
+		}
+		if !config.OAuthScopes.IsNull() {
+			resp.Diagnostics.Append(config.OAuthScopes.ElementsAs(ctx, &oauth.Scopes, false)...)
+			if resp.Diagnostics.HasError() {
+				return
+			}
+		}
+	}
+	var tokens oauth2.TokenSource
+	if oauth != nil {
+		if oauth.ClientID == "" || oauth.ClientSecret == "" {
+			resp.Diagnostics.AddError("Incomplete OAuth2 settings",
+				"Both \"oauth_client_id\" and \"oauth_client_secret\" must be set with \"oauth_token_url\".")
+			return
+		}
+
+		// The token source outlives Configure, refreshing tokens during apply
+		tokens = oauth.TokenSource(context.Background())
+		if _, err := tokens.Token(); err != nil {
+			resp.Diagnostics.AddError("Failed to obtain OAuth2 access token", err.Error())
+			return
+		}
+	}
+
+	// Resolve request signing: config values > environment variables
+	signingKey := config.SigningKey.ValueString()
+	if signingKey == "" {
+		signingKey = os.Getenv("KAIAK_SIGNING_KEY")
+	}
+	signingAlgorithm := config.SigningAlgorithm.ValueString()
+	if signingAlgorithm == "" {
+		signingAlgorithm = os.Getenv("KAIAK_SIGNING_ALGORITHM")
+	}
+	signer, err := newRequestSigner(signingKey, signingAlgorithm)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("signing_algorithm"), "Invalid signing settings", err.Error())
+		return
+	}
+
+	// Resolve the per-resource-type settings, and check those in the provider
+	// block match the environment variables the schemas were built with
+	normalizeLists := resolveMap[[]string](ctx, config.NormalizeLists, "", &resp.Diagnostics)
+	clearAttributes := resolveMap[[]string](ctx, config.ClearAttributes, "", &resp.Diagnostics)
+	emptyAsNull := resolveMap[[]string](ctx, config.EmptyAsNull, "", &resp.Diagnostics)
+	transforms := resolveMap[map[string][]string](ctx, config.TransformAttributes, "", &resp.Diagnostics)
+	defaultAttributes := resolveMap[map[string]string](ctx, config.DefaultAttributes, "", &resp.Diagnostics)
+	statusAttributes := resolveMap[string](ctx, config.StatusAttributes, "", &resp.Diagnostics)
+	settings := resolveSchemaSettings(ctx)
+	if checkSchemaSettings(ctx, config, settings, &resp.Diagnostics); resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Parse delete completion polling settings
+	var deletePoll time.Duration
+	deleteTimeout := defaultDeleteTimeout
+	retryRefill := defaultRetryBudgetRefill
+	for _, d := range []struct {
+		name  string
+		value types.String
+		dest  *time.Duration
+	}{
+		{"delete_poll_interval", config.DeletePollInterval, &deletePoll},
+		{"delete_timeout", config.DeleteTimeout, &deleteTimeout},
+		{"retry_budget_refill", config.RetryBudgetRefill, &retryRefill},
+	} {
+		if d.value.IsNull() || d.value.IsUnknown() {
+			continue
+		}
+		v, err := parsePositiveDuration(d.value.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root(d.name), "Invalid duration", err.Error())
+			return
+		}
+		*d.dest = v
 	}
 
 	// Cache resolved values so Resources() uses the same settings
 	p.endpoint = endpoint
 	p.apiKey = apiKey
+	p.endpoints = endpoints
+	p.userAgent = userAgent(p.version, req.TerraformVersion, config.UserAgentSuffix.ValueString())
+	p.tokens = tokens
+	p.oauth = oauth
+	p.signer = signer
+	p.auth = &auth
+	p.schema = schemaFile
+	p.workers = int(config.DiscoveryWorkers.ValueInt64())
+	p.maxResp = defaultMaxResponseSize
+	if !config.MaxResponseSize.IsNull() && !config.MaxResponseSize.IsUnknown() {
+		p.maxResp = config.MaxResponseSize.ValueInt64()
+	}
+
+	// Create the HTTP client, with a transport tuned before any wrapping.
+	// Each option wraps the transport of the previous one, so the last runs
+	// first on a request
+	opts := []client.ClientOpt{
+		optTransport(config.MaxIdleConns, config.MaxIdleConnsPerHost, config.ForceHTTP2),
+	}
+	opts = append(opts, clientOpts(apiKey, auth, p.userAgent, tokens, signer, p.maxResp)...)
+
+	// Retries wrap authentication and signing, so that each attempt is
+	// signed afresh rather than replaying the first signature
+	if maxRetries := config.MaxRetries.ValueInt64(); maxRetries > 0 {
+		budget := int64(defaultRetryBudget)
+		if !config.RetryBudget.IsNull() {
+			budget = config.RetryBudget.ValueInt64()
+		}
+		var codes []int
+		if !config.RetryableStatusCodes.IsNull() && !config.RetryableStatusCodes.IsUnknown() {
+			resp.Diagnostics.Append(config.RetryableStatusCodes.ElementsAs(ctx, &codes, false)...)
+			if resp.Diagnostics.HasError() {
+				return
+			}
+		}
+		connErrors := config.RetryConnErrors.IsNull() || config.RetryConnErrors.ValueBool()
+		opts = append(opts, optRetry(int(maxRetries), newRetryBudget(int(budget), retryRefill), codes, connErrors))
+	}
 
-	// Create the HTTP client
-	cl, err := httpclient.New(endpoint, clientOpts(apiKey)...)
+	// Bodies are compressed before they are signed or traced, so that the
+	// signature covers the bytes sent
+	if config.CompressRequests.ValueBool() {
+		opts = append(opts, optCompression(compressMinSize))
+	}
+	cl, err := httpclient.New(endpoint, opts...)
 	if err != nil {
 		resp.Diagnostics.AddError("Failed to create Kaiak client", err.Error())
 		return
 	}
 
-	// Make the client available to resources and data sources
-	resp.DataSourceData = cl
-	resp.ResourceData = cl
+	// Create a client for each overridden resource type
+	overrides := make(map[string]*httpclient.Client, len(endpoints))
+	for resourceType, override := range endpoints {
+		ocl, err := httpclient.New(override, opts...)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("endpoints").AtMapKey(resourceType),
+				"Failed to create Kaiak client", err.Error())
+			return
+		}
+		overrides[resourceType] = ocl
+	}
+
+	// Batch the reads made with each client
+	var batchers map[*httpclient.Client]*readBatcher
+	if config.BatchReads.ValueBool() {
+		batchers = map[*httpclient.Client]*readBatcher{cl: newReadBatcher(cl)}
+		for _, ocl := range overrides {
+			batchers[ocl] = newReadBatcher(ocl)
+		}
+	}
+
+	// Make the client and settings available to resources and data sources
+	// Workspace metadata and the managed-by marker stamped on instances
+	stamps := workspaceStamps(ctx, config.WorkspaceAttribute.ValueString(), config.RunIDAttribute.ValueString())
+	if name := config.ManagedByAttribute.ValueString(); name != "" {
+		stamps[name] = managedByMarker
+	}
+
+	data := &providerData{
+		client:            cl,
+		overrides:         overrides,
+		strictConsistency: config.StrictConsistency.ValueBool(),
+		checkReferences:   config.CheckReferences.ValueBool(),
+		forceDestroy:      config.ForceDestroy.ValueBool(),
+		deleteMode:        config.DeleteMode.ValueString(),
+		renameInPlace:     config.AllowRenameInPlace.ValueBool(),
+		normalizeLists:    normalizeLists,
+		clearAttributes:   clearAttributes,
+		emptyAsNull:       emptyAsNull,
+		transforms:        transforms,
+		statusAttributes:  statusAttributes,
+		defaultAttributes: defaultAttributes,
+		deletePoll:        deletePoll,
+		deleteTimeout:     deleteTimeout,
+		timeFormat:        config.TimeFormat.ValueString(),
+		keyCase:           config.StateKeyCase.ValueString(),
+		stagedCreate:      config.StagedCreate.ValueBool(),
+		batchers:          batchers,
+		maxBodySize:       config.MaxBodySize.ValueInt64(),
+		stamps:            stamps,
+		managedBy:         config.ManagedByAttribute.ValueString(),
+		readOnly:          config.ReadOnly.ValueBool(),
+		schema:            settings,
+		stats:             p.stats,
+		summary:           p.summary,
+	}
+	resp.DataSourceData = data
+	resp.ResourceData = data
+}
+
+// ValidateConfig checks the combinations of provider attributes, reporting
+// every problem at once with the attribute it concerns. It runs before
+// Configure. Unknown values are not checked, as they may yet be valid, and
+// settings from environment variables are checked in Configure.
+func (p *kaiakProvider) ValidateConfig(ctx context.Context, req provider.ValidateConfigRequest, resp *provider.ValidateConfigResponse) {
+	var config kaiakProviderModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	set := func(v attr.Value) bool {
+		return !v.IsNull() && !v.IsUnknown()
+	}
+
+	// At most one authentication method
+	if set(config.ApiKey) && set(config.ApiKeyFile) {
+		resp.Diagnostics.AddAttributeError(path.Root("api_key_file"), "Conflicting API key settings",
+			"Only one of \"api_key\" and \"api_key_file\" can be set.")
+	}
+	if set(config.OAuthTokenURL) && (set(config.ApiKey) || set(config.ApiKeyFile)) {
+		resp.Diagnostics.AddAttributeError(path.Root("oauth_token_url"), "Conflicting authentication settings",
+			"Only one of \"oauth_token_url\" and \"api_key\" or \"api_key_file\" can be set.")
+	}
+
+	// OAuth2 client credentials are required with, and only used with, a token URL
+	oauth := []struct {
+		name  string
+		value attr.Value
+	}{
+		{"oauth_client_id", config.OAuthClientID},
+		{"oauth_client_secret", config.OAuthClientSecret},
+		{"oauth_scopes", config.OAuthScopes},
+	}
+	for _, o := range oauth {
+		switch {
+		case set(config.OAuthTokenURL) && o.value.IsNull() && o.name != "oauth_scopes":
+			resp.Diagnostics.AddAttributeError(path.Root(o.name), "Incomplete OAuth2 settings",
+				fmt.Sprintf("\"%s\" must be set with \"oauth_token_url\".", o.name))
+		case config.OAuthTokenURL.IsNull() && set(o.value):
+			resp.Diagnostics.AddAttributeError(path.Root(o.name), "Incomplete OAuth2 settings",
+				fmt.Sprintf("\"%s\" is only used with \"oauth_token_url\", which is not set.", o.name))
+		}
+	}
+
+	// Each stamped attribute holds one value
+	if set(config.ManagedByAttribute) {
+		for _, a := range []struct {
+			name  string
+			value types.String
+		}{
+			{"workspace_attribute", config.WorkspaceAttribute},
+			{"run_id_attribute", config.RunIDAttribute},
+		} {
+			if set(a.value) && a.value.ValueString() == config.ManagedByAttribute.ValueString() {
+				resp.Diagnostics.AddAttributeError(path.Root("managed_by_attribute"), "Conflicting stamp settings",
+					fmt.Sprintf("\"managed_by_attribute\" and \"%s\" cannot name the same attribute.", a.name))
+			}
+		}
+	}
+
+	// Transforms must be known
+	if set(config.TransformAttributes) {
+		transforms := resolveMap[map[string][]string](ctx, config.TransformAttributes, "", &resp.Diagnostics)
+		for resourceType, attrs := range transforms {
+			for name, names := range attrs {
+				if err := checkTransforms(names); err != nil {
+					resp.Diagnostics.AddAttributeError(path.Root("transform_attributes").AtMapKey(resourceType).AtMapKey(name),
+						"Invalid transform", err.Error())
+				}
+			}
+		}
+	}
+
+	// Durations must parse and be positive
+	for _, d := range []struct {
+		name  string
+		value types.String
+	}{
+		{"delete_poll_interval", config.DeletePollInterval},
+		{"delete_timeout", config.DeleteTimeout},
+	} {
+		if !set(d.value) {
+			continue
+		}
+		if _, err := parsePositiveDuration(d.value.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root(d.name), "Invalid duration", err.Error())
+		}
+	}
+
+	// Settings which have no effect without another
+	for _, a := range []struct {
+		name  string
+		value attr.Value
+	}{
+		{"auth_header", config.AuthHeader},
+		{"auth_scheme", config.AuthScheme},
+	} {
+		if set(a.value) && set(config.OAuthTokenURL) {
+			resp.Diagnostics.AddAttributeWarning(path.Root(a.name), "Ignored setting",
+				fmt.Sprintf("%q is ignored when \"oauth_token_url\" is set.", a.name))
+		}
+	}
+	if set(config.Port) && set(config.Endpoint) {
+		resp.Diagnostics.AddAttributeWarning(path.Root("port"), "Ignored setting",
+			"\"port\" is ignored when \"endpoint\" is set.")
+	}
+	if set(config.Profile) && set(config.Endpoint) && (set(config.ApiKey) || set(config.ApiKeyFile)) {
+		resp.Diagnostics.AddAttributeWarning(path.Root("profile"), "Ignored setting",
+			"\"profile\" is ignored when \"endpoint\" and an API key are set.")
+	}
+	for _, retry := range []struct {
+		name  string
+		value attr.Value
+	}{
+		{"retry_budget", config.RetryBudget},
+		{"retry_budget_refill", config.RetryBudgetRefill},
+		{"retryable_status_codes", config.RetryableStatusCodes},
+		{"retry_connection_errors", config.RetryConnErrors},
+	} {
+		if set(retry.value) && config.MaxRetries.ValueInt64() == 0 && !config.MaxRetries.IsUnknown() {
+			resp.Diagnostics.AddAttributeWarning(path.Root(retry.name), "Ignored setting",
+				fmt.Sprintf("%q is ignored unless \"max_retries\" is set.", retry.name))
+		}
+	}
+	if set(config.DeleteTimeout) && config.DeletePollInterval.IsNull() {
+		resp.Diagnostics.AddAttributeWarning(path.Root("delete_timeout"), "Ignored setting",
+			"\"delete_timeout\" is ignored unless \"delete_poll_interval\" is set.")
+	}
 }
 
 // Resources discovers resource types from the running Kaiak server and
 // returns a factory for each one. The server must be reachable at schema-
-// discovery time (i.e. during terraform plan / apply).
+// discovery time (i.e. during terraform plan / apply), unless a schema file
+// is set, in which case the resource types are loaded from the file.
 //
 // When Configure() has already run, the provider-configured endpoint and
 // API key are used. Otherwise (e.g. during validate or early plan phases)
 // the values fall back to KAIAK_ENDPOINT / KAIAK_API_KEY env vars.
 func (p *kaiakProvider) Resources(ctx context.Context) []func() resource.Resource {
-	// Prefer values cached from Configure(); fall back to env vars
+	metas, err := p.discover(ctx)
+	p.discoveryErr = err
+	return resourceFactories(availableResources(ctx, metas), resolveSchemaSettings(ctx))
+}
+
+// discover returns the resource types from the schema file or the servers.
+// Errors are logged, and also returned so that Configure can report them
+// with fail_on_discovery_error, together with any resource types which were
+// discovered.
+func (p *kaiakProvider) discover(ctx context.Context) ([]resourceMeta, error) {
+	schemaFile := p.schema
+	if schemaFile == "" {
+		schemaFile = os.Getenv("KAIAK_SCHEMA_FILE")
+	}
+	if schemaFile != "" {
+		metas, err := loadSchemaFile(schemaFile)
+		if err != nil {
+			logError(ctx, "Failed to read schema file. No resources will be available.", map[string]interface{}{
+				"error": err.Error(),
+			})
+			return nil, fmt.Errorf("failed to read schema file: %w", err)
+		}
+		return metas, nil
+	}
+
+	// Prefer values cached from Configure(); fall back to the profile named
+	// by KAIAK_PROFILE and env vars
+	prof, err := resolveProfile("")
+	if err != nil {
+		logError(ctx, "Failed to read profile. No resources will be available.", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return nil, fmt.Errorf("failed to read profile: %w", err)
+	}
 	endpoint := p.endpoint
 	if endpoint == "" {
-		endpoint = resolveEndpoint()
+		endpoint = resolveEndpoint(prof, 0)
 	}
 
 	apiKey := p.apiKey
 	if apiKey == "" {
-		apiKey = resolveApiKey()
+		v, err := resolveApiKey(prof)
+		if err != nil {
+			logError(ctx, "Failed to read API key file. No resources will be available.", map[string]interface{}{
+				"error": err.Error(),
+			})
+			return nil, fmt.Errorf("failed to read API key file: %w", err)
+		}
+		apiKey = v
 	}
 
-	cl, err := httpclient.New(endpoint, clientOpts(apiKey)...)
-	if err != nil {
-		tflog.Error(ctx, "Failed to create Kaiak client. No resources will be available.", map[string]interface{}{
-			"endpoint": endpoint,
-			"error":    err.Error(),
-		})
-		return nil
+	endpoints := p.endpoints
+	if endpoints == nil {
+		endpoints = resolveEndpoints()
 	}
 
-	result, err := cl.ListResources(ctx, schema.ListResourcesRequest{})
-	if err != nil {
-		tflog.Error(ctx, "Failed to discover resources from Kaiak server. No resources will be available.", map[string]interface{}{
-			"endpoint": endpoint,
-			"error":    err.Error(),
-		})
-		return nil
+	ua := p.userAgent
+	if ua == "" {
+		ua = userAgent(p.version, "", "")
+	}
+	oauth, tokens := p.oauth, p.tokens
+	if tokens == nil {
+		if oauth = resolveOAuth(); oauth != nil {
+			tokens = oauth.TokenSource(context.Background())
+		}
+	}
+	signer := p.signer
+	if signer == nil {
+		v, err := resolveSigner()
+		if err != nil {
+			logError(ctx, "Invalid request signing settings. No resources will be available.", map[string]interface{}{
+				"error": err.Error(),
+			})
+			return nil, fmt.Errorf("invalid request signing settings: %w", err)
+		}
+		signer = v
+	}
+	auth := resolveKeyAuth()
+	if p.auth != nil {
+		auth = *p.auth
+	}
+	maxResp := p.maxResp
+	if maxResp == 0 {
+		maxResp = defaultMaxResponseSize
+	}
+	opts := clientOpts(apiKey, auth, ua, tokens, signer, maxResp)
+	credentials := credentialsDigest(apiKey, auth, oauth, signer)
+	workers := p.workers
+	if workers == 0 {
+		workers = resolveDiscoveryWorkers()
 	}
 
-	factories := make([]func() resource.Resource, 0, len(result.Resources))
-	for _, r := range result.Resources {
-		meta := r // capture
-		factories = append(factories, func() resource.Resource {
-			return newDynamicResource(meta)
-		})
+	// Resource types without an override are discovered from the default endpoint
+	var metas []resourceMeta
+	discovered, err := discoveries.discover(ctx, endpoint, credentials, opts, workers)
+	errs := []error{err}
+	for _, meta := range discovered {
+		if _, ok := endpoints[meta.Name]; !ok {
+			metas = append(metas, meta)
+		}
 	}
-	return factories
+
+	// Resource types with an override are discovered from their own server
+	overrides := map[string][]string{} // endpoint → resource types
+	for resourceType, override := range endpoints {
+		overrides[override] = append(overrides[override], resourceType)
+	}
+	for override, resourceTypes := range overrides {
+		discovered, err := discoveries.discover(ctx, override, credentials, opts, workers)
+		errs = append(errs, err)
+		for _, meta := range discovered {
+			if slices.Contains(resourceTypes, meta.Name) {
+				metas = append(metas, meta)
+			}
+		}
+	}
+
+	return metas, errors.Join(errs...)
 }
 
 func (p *kaiakProvider) DataSources(_ context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
 		NewResourcesDataSource,
+		NewInstanceHistoryDataSource,
+		NewResourceSchemaDataSource,
+		NewOrphansDataSource,
 	}
 }
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	// Packages
+	attr "github.com/hashicorp/terraform-plugin-framework/attr"
+	diag "github.com/hashicorp/terraform-plugin-framework/diag"
+	provider "github.com/hashicorp/terraform-plugin-framework/provider"
+	tfsdk "github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	types "github.com/hashicorp/terraform-plugin-framework/types"
+	tfprotov6 "github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	tftypes "github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+// configure returns a new provider configured with the given provider block
+// attributes, with all others null, and the data it makes available to
+// resources.
+func configure(t *testing.T, attrs map[string]tftypes.Value) (*kaiakProvider, *providerData) {
+	t.Helper()
+	p, resp := configureResponse(t, attrs)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("Configure: %v", resp.Diagnostics)
+	}
+	data, ok := resp.ResourceData.(*providerData)
+	if !ok {
+		t.Fatal("Configure did not set the resource data")
+	}
+	return p, data
+}
+
+// configureResponse returns a new provider configured with the given
+// provider block attributes, with all others null, and its response.
+func configureResponse(t *testing.T, attrs map[string]tftypes.Value) (*kaiakProvider, *provider.ConfigureResponse) {
+	t.Helper()
+	ctx := context.Background()
+	p := New("test")().(*kaiakProvider)
+
+	var schemaResp provider.SchemaResponse
+	p.Schema(ctx, provider.SchemaRequest{}, &schemaResp)
+	typ := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	values := make(map[string]tftypes.Value, len(typ.AttributeTypes))
+	for name, attrType := range typ.AttributeTypes {
+		values[name] = tftypes.NewValue(attrType, nil)
+	}
+	for name, v := range attrs {
+		values[name] = v
+	}
+
+	var resp provider.ConfigureResponse
+	p.Configure(ctx, provider.ConfigureRequest{
+		Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: tftypes.NewValue(typ, values)},
+	}, &resp)
+	return p, &resp
+}
+
+// stringValue returns a terraform string value.
+func stringValue(s string) tftypes.Value {
+	return tftypes.NewValue(tftypes.String, s)
+}
+
+// TestDiscoverAliases checks that aliased providers configured with
+// different endpoints each discover the resource types of their own server,
+// and that discovering them again uses the cache.
+func TestDiscoverAliases(t *testing.T) {
+	servers := []*testServer{
+		newTestServer(t, resourceMeta{Name: "a", Attributes: []attributeMeta{attribute("x", "string")}}),
+		newTestServer(t, resourceMeta{Name: "b", Attributes: []attributeMeta{attribute("y", "string")}}),
+	}
+	hasTypes := func(p *testProvider, want string) {
+		t.Helper()
+		if len(p.schemas) != 1 || p.schemas[want] == nil {
+			t.Errorf("resource types %v, want %s", slices.Collect(maps.Keys(p.schemas)), want)
+		}
+	}
+
+	// Each alias runs in its own process, which discovers from KAIAK_ENDPOINT
+	for range 2 {
+		for _, srv := range servers {
+			p := newTestProvider(t, srv, nil)
+			hasTypes(p, "kaiak_"+srv.resources[0].Name)
+		}
+	}
+
+	// The endpoint in the provider block is read after discovery
+	p := newTestProvider(t, servers[0], map[string]tftypes.Value{"endpoint": stringValue(servers[1].URL)})
+	hasTypes(p, "kaiak_a")
+
+	// Discovery from each server is cached, and not repeated
+	for _, srv := range servers {
+		srv.Lock()
+		if srv.lists != 1 {
+			t.Errorf("%s: listed resource types %d times, want once", srv.URL, srv.lists)
+		}
+		srv.Unlock()
+	}
+}
+
+func TestUnreachableDefaultEndpoint(t *testing.T) {
+	// Nothing listens on the default endpoint
+	l, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := int64(l.Addr().(*net.TCPAddr).Port)
+	l.Close()
+	t.Setenv("KAIAK_ENDPOINT", "")
+	t.Setenv("KAIAK_PROFILE", "")
+	t.Setenv("KAIAK_SCHEMA_FILE", "")
+	attrs := map[string]tftypes.Value{"port": tftypes.NewValue(tftypes.Number, port)}
+
+	// Without a schema file, the provider cannot work offline
+	if _, resp := configureResponse(t, attrs); !resp.Diagnostics.HasError() {
+		t.Error("expected an error for an unreachable default endpoint")
+	}
+
+	// With a schema file, it is a warning
+	schemaFile := filepath.Join(t.TempDir(), "schema.json")
+	if err := os.WriteFile(schemaFile, []byte(`{"resources":[]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	attrs["schema_file"] = stringValue(schemaFile)
+	_, resp := configureResponse(t, attrs)
+	if resp.Diagnostics.HasError() {
+		t.Errorf("Configure: %v", resp.Diagnostics)
+	}
+	if resp.Diagnostics.WarningsCount() != 1 {
+		t.Errorf("warnings %v, want the unreachable endpoint", resp.Diagnostics.Warnings())
+	}
+}
+
+func TestSchemaSettingsFromEnvironment(t *testing.T) {
+	t.Setenv("KAIAK_DESCRIPTIONS", `{"x": "from the environment"}`)
+	t.Setenv("KAIAK_ATTRIBUTE_SEPARATOR", "/")
+	srv := newTestServer(t, resourceMeta{Name: "x", Attributes: []attributeMeta{attribute("tls/cert", "string")}})
+	descriptions := func(s string) tftypes.Value {
+		return tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, map[string]tftypes.Value{"x": stringValue(s)})
+	}
+	tests := []struct {
+		name  string
+		attrs map[string]tftypes.Value
+		err   string // attribute with an error, if any
+	}{
+		{"unset", nil, ""},
+		{"same", map[string]tftypes.Value{"descriptions": descriptions("from the environment"), "attribute_separator": stringValue("/")}, ""},
+		{"different", map[string]tftypes.Value{"descriptions": descriptions("from the provider")}, "descriptions"},
+		{"not in the environment", map[string]tftypes.Value{"strict_types": tftypes.NewValue(tftypes.Bool, true)}, "strict_types"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, diags := serveProvider(t, srv, tt.attrs)
+
+			// The schemas are built from the environment, whatever the provider block
+			s := p.schemas["kaiak_x"]
+			if s == nil {
+				t.Fatal("no schema for kaiak_x")
+			}
+			if s.Block.Description != "from the environment" {
+				t.Errorf("description %q, want the one from the environment", s.Block.Description)
+			}
+			if !slices.ContainsFunc(s.Block.Attributes, func(a *tfprotov6.SchemaAttribute) bool { return a.Name == "tls" }) {
+				t.Error("tls/cert is not split into a block")
+			}
+
+			// A provider block setting which differs is an error
+			var errs []string
+			for _, d := range diags {
+				if d.Severity == tfprotov6.DiagnosticSeverityError && d.Attribute != nil {
+					errs = append(errs, d.Attribute.String())
+				}
+			}
+			switch {
+			case tt.err == "" && len(errs) > 0:
+				t.Errorf("errors on %v, want none", errs)
+			case tt.err != "" && (len(errs) != 1 || !strings.Contains(errs[0], tt.err)):
+				t.Errorf("errors on %v, want one on %s", errs, tt.err)
+			}
+		})
+	}
+}
+
+func TestResolveMap(t *testing.T) {
+	ctx := context.Background()
+	listType := types.ListType{ElemType: types.StringType}
+	configured := types.MapValueMust(listType, map[string]attr.Value{
+		"x": types.ListValueMust(types.StringType, []attr.Value{types.StringValue("config")}),
+	})
+	tests := []struct {
+		name    string
+		setting types.Map
+		env     string // value of KAIAK_TEST_MAP
+		want    []string
+	}{
+		{"configured", configured, `{"x": ["env"]}`, []string{"config"}},
+		{"environment", types.MapNull(listType), `{"x": ["env"]}`, []string{"env"}},
+		{"unknown", types.MapUnknown(listType), `{"x": ["env"]}`, []string{"env"}},
+		{"invalid", types.MapNull(listType), `{"x": "env"}`, nil},
+		{"unset", types.MapNull(listType), "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("KAIAK_TEST_MAP", tt.env)
+			var diags diag.Diagnostics
+			got := resolveMap[[]string](ctx, tt.setting, "KAIAK_TEST_MAP", &diags)
+			if diags.HasError() {
+				t.Fatal(diags)
+			}
+			if !slices.Equal(got["x"], tt.want) {
+				t.Errorf("got %v, want %v", got["x"], tt.want)
+			}
+		})
+	}
+
+	// Without an environment variable, there is no fallback
+	t.Setenv("KAIAK_TEST_MAP", `{"x": ["env"]}`)
+	var diags diag.Diagnostics
+	if got := resolveMap[[]string](ctx, types.MapNull(listType), "", &diags); got != nil {
+		t.Errorf("got %v, want no fallback", got)
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	// Packages
+	diag "github.com/hashicorp/terraform-plugin-framework/diag"
+	tflog "github.com/hashicorp/terraform-plugin-log/tflog"
+	client "github.com/mutablelogic/go-client"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// requestIDKey is the context key for the correlation id of an operation.
+type requestIDKey struct{}
+
+// requestIDTransport sets the correlation id of the operation, if any, as a
+// header on every request made within it.
+type requestIDTransport struct {
+	base http.RoundTripper
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// requestIDHeader is the header which carries the correlation id
+	requestIDHeader = "X-Request-Id"
+
+	// requestIDField is the log field which carries the correlation id
+	requestIDField = "request_id"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// withRequestID returns a context with a new correlation id for a CRUD
+// operation, which is added to every log line within it.
+func withRequestID(ctx context.Context) context.Context {
+	id := randomHex(8)
+	ctx = context.WithValue(ctx, requestIDKey{}, id)
+	return tflog.SetField(ctx, requestIDField, id)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// RoundTrip sends a copy of the request with the correlation id header,
+// leaving the original unmodified as RoundTripper requires.
+func (t *requestIDTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id, ok := requestID(req.Context())
+	if !ok || req.Header.Get(requestIDHeader) != "" {
+		return t.base.RoundTrip(req)
+	}
+	r := req.Clone(req.Context())
+	r.Header.Set(requestIDHeader, id)
+	return t.base.RoundTrip(r)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// requestID returns the correlation id of the operation, if any.
+func requestID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
+
+// tagDiagnostics appends the correlation id of the operation to the detail of
+// each diagnostic, so that it can be matched with the server logs.
+func tagDiagnostics(ctx context.Context, diags *diag.Diagnostics) {
+	id, ok := requestID(ctx)
+	if !ok {
+		return
+	}
+	for i, d := range *diags {
+		detail := d.Detail() + " (request id " + id + ")"
+		var tagged diag.Diagnostic = diag.NewWarningDiagnostic(d.Summary(), detail)
+		if d.Severity() == diag.SeverityError {
+			tagged = diag.NewErrorDiagnostic(d.Summary(), detail)
+		}
+		if withPath, ok := d.(diag.DiagnosticWithPath); ok {
+			tagged = diag.WithPath(withPath.Path(), tagged)
+		}
+		(*diags)[i] = tagged
+	}
+}
+
+// optRequestID sets the correlation id header on requests made within an
+// operation.
+func optRequestID() client.ClientOpt {
+	return func(c *client.Client) error {
+		c.Transport = &requestIDTransport{base: c.Transport}
+		return nil
+	}
+}
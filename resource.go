@@ -1,19 +1,38 @@
 package main
 
 import (
+	"cmp"
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
 
 	// Packages
+	stringvalidator "github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	attr "github.com/hashicorp/terraform-plugin-framework/attr"
 	diag "github.com/hashicorp/terraform-plugin-framework/diag"
 	path "github.com/hashicorp/terraform-plugin-framework/path"
 	resource "github.com/hashicorp/terraform-plugin-framework/resource"
+	tfschema "github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	planmodifier "github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	stringplanmodifier "github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	validator "github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	tfsdk "github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	types "github.com/hashicorp/terraform-plugin-framework/types"
+	tftypes "github.com/hashicorp/terraform-plugin-go/tftypes"
 	httpclient "github.com/mutablelogic/go-server/pkg/provider/httpclient"
 	schema "github.com/mutablelogic/go-server/pkg/provider/schema"
 )
@@ -24,25 +43,145 @@ import (
 // dynamicResource implements a Terraform resource whose schema is discovered
 // at runtime from the Kaiak server.
 type dynamicResource struct {
-	client *httpclient.Client
-	meta   schema.ResourceMeta
-	infos  []attrInfo
+	client  *httpclient.Client
+	meta    resourceMeta
+	infos   []attrInfo
+	strict  bool              // verify applied attributes against the server after apply
+	refs    bool              // check referenced instances exist at plan time
+	force   bool              // cascade deletes to dependent instances
+	delete  string            // delete mode, purge (or empty) or retain
+	lists   []string          // list attributes compared as sets
+	clears  []string          // optional attributes cleared when removed from config
+	empties []string          // string attributes for which an empty string is null
+	status  string            // key of the instance state holding the runtime status, if set
+	times   string            // representation of time values in state
+	keyCase string            // casing of the keys of instance state from the server
+	staged  bool              // create instances with their attributes in one request
+	reads   *readBatcher      // batches reads of instances, or nil
+	maxBody int64             // maximum size of the attributes sent in a request, or zero
+	stamps  map[string]string // attribute name → workspace metadata set on create and update
+	managed string            // attribute holding the managed-by marker, if set
+	ro      bool              // fail every create, update and delete
+	renames bool              // rename instances in place when their label changes
+
+	deletePoll    time.Duration // interval to poll for delete completion, or zero
+	deleteTimeout time.Duration // maximum time to wait for delete completion
+	stats         *latencyStats
+	summary       *applySummary
+
+	defs       map[string]attr.Value // attribute name → value planned when not set in configuration
+	transforms map[string][]string   // attribute name → transforms of values sent to the server
+}
+
+// privateGetter is satisfied by the private state passed to resource methods.
+type privateGetter interface {
+	GetKey(context.Context, string) ([]byte, diag.Diagnostics)
+}
+
+// privateSetter is satisfied by the private state returned from resource
+// methods.
+type privateSetter interface {
+	SetKey(context.Context, string, []byte) diag.Diagnostics
+}
+
+// serverError is the JSON body of an error response from the server.
+type serverError struct {
+	Code   int    `json:"code"`
+	Reason string `json:"reason,omitempty"`
+	Detail any    `json:"detail,omitempty"`
+}
+
+// urlAttribute is the name of the computed attribute composed from a URL
+// template.
+const urlAttribute = "url"
+
+// statusAttribute is the name of the computed attribute holding the
+// runtime status of an instance.
+const statusAttribute = "status"
+
+// uuidAttribute is the name of the computed attribute holding the stable
+// identifier which the server assigns to an instance.
+const uuidAttribute = "uuid"
+
+// labelAttribute is the name of the attribute holding the instance label,
+// which is generated unless set in configuration.
+const labelAttribute = "label"
+
+// labelPrefix starts every instance label generated by the provider.
+const labelPrefix = "tf_"
+
+// generatedLabel matches an instance label generated by the provider.
+var generatedLabel = regexp.MustCompile(`^` + labelPrefix + `[0-9a-f]{8}$`)
+
+// urlPlaceholder matches an attribute name in braces in a URL template.
+var urlPlaceholder = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// Modes of deleting an instance: purge removes it outright, and retain
+// keeps it on the server for recovery
+const (
+	deleteModePurge  = "purge"
+	deleteModeRetain = "retain"
+)
+
+// Casing of the keys of instance state from the server
+const (
+	keyCaseAsIs  = "as-is"
+	keyCaseSnake = "snake"
+	keyCaseCamel = "camel"
+)
+
+// redactedError is an error whose message has sensitive values masked.
+type redactedError struct {
+	msg string
+	err error
 }
 
+// redactedValue replaces a sensitive value in an error message, and values
+// shorter than minRedactLength are not replaced.
+const (
+	redactedValue   = "(sensitive value)"
+	minRedactLength = 4
+)
+
+// operationStartKey is the context key for the start time of an operation.
+type operationStartKey struct{}
+
+// schemaHashKey is the private state key holding the hash of the resource
+// schema a plan was made against.
+const schemaHashKey = "schema_hash"
+
+// appliedSchemaKey is the private state key holding the hash of the resource
+// schema the instance was last applied or refreshed with.
+const appliedSchemaKey = "applied_schema_hash"
+
+// versionKey is the private state key holding the version of the instance
+// last read from the server, for servers which report one.
+const versionKey = "version"
+
 // attrGetter is satisfied by tfsdk.Config, tfsdk.Plan, and tfsdk.State.
 type attrGetter interface {
 	GetAttribute(context.Context, path.Path, any) diag.Diagnostics
 }
 
+// warnedStamps records the resource type and attribute pairs already warned
+// about when stamping workspace metadata, so each is only logged once.
+var warnedStamps sync.Map
+
+// warnedURLs records the resource types already warned about for having
+// their own url attribute, so each is only logged once.
+var warnedURLs sync.Map
+
 var _ resource.Resource = (*dynamicResource)(nil)
 var _ resource.ResourceWithImportState = (*dynamicResource)(nil)
+var _ resource.ResourceWithModifyPlan = (*dynamicResource)(nil)
+var _ resource.ResourceWithConfigValidators = (*dynamicResource)(nil)
 
 // getInfos returns the cached attrInfo slice, building it on first call.
 // This is necessary because the Terraform framework may call Schema() on one
 // resource instance and CRUD methods on a different instance.
 func (r *dynamicResource) getInfos() []attrInfo {
 	if r.infos == nil {
-		_, infos, _ := buildResourceSchema(r.meta.Name, r.meta.Attributes)
+		_, infos, _ := buildResourceSchema(r.meta)
 		r.infos = infos
 	}
 	return r.infos
@@ -51,7 +190,7 @@ func (r *dynamicResource) getInfos() []attrInfo {
 ///////////////////////////////////////////////////////////////////////////////
 // LIFECYCLE
 
-func newDynamicResource(meta schema.ResourceMeta) *dynamicResource {
+func newDynamicResource(meta resourceMeta) *dynamicResource {
 	return &dynamicResource{meta: meta}
 }
 
@@ -60,11 +199,34 @@ func (r *dynamicResource) fullName(label string) string {
 	return r.meta.Name + "." + label
 }
 
+// parseInstanceName splits a fully qualified instance name of the form
+// "resource_type.label" into its resource type and label.
+func parseInstanceName(name string) (string, string, error) {
+	parts := strings.SplitN(name, ".", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("expected format \"resource_type.label\" (e.g. \"httpstatic.docs\"), got %q", name)
+	}
+	return parts[0], parts[1], nil
+}
+
 // generateLabel returns a short random hex string for use as an instance label.
+// A generated label is unique, so a replacement instance can coexist with the
+// instance it replaces under create_before_destroy.
 func generateLabel() string {
-	b := make([]byte, 4)
+	return labelPrefix + randomHex(4)
+}
+
+// randomHex returns n random bytes as a hex string.
+func randomHex(n int) string {
+	b := make([]byte, n)
 	_, _ = rand.Read(b)
-	return "tf_" + hex.EncodeToString(b)
+	return hex.EncodeToString(b)
+}
+
+// isGeneratedLabel returns true if an instance label has the form of those
+// from generateLabel, so the instance was created by the provider.
+func isGeneratedLabel(label string) bool {
+	return generatedLabel.MatchString(label)
 }
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -74,27 +236,107 @@ func (r *dynamicResource) Metadata(_ context.Context, req resource.MetadataReque
 	resp.TypeName = req.ProviderTypeName + "_" + r.meta.Name
 }
 
-func (r *dynamicResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
-	s, infos, diags := buildResourceSchema(r.meta.Name, r.meta.Attributes)
+func (r *dynamicResource) Schema(ctx context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	warnUnknownTypes(ctx, r.meta.Name, r.meta.Attributes)
+	s, infos, diags := buildResourceSchema(r.meta)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 	r.infos = infos
+
+	// A description from the server or the environment replaces the generated one
+	if r.meta.Description != "" {
+		s.Description = r.meta.Description
+		s.MarkdownDescription = r.meta.Description
+	}
+
+	// The computed url attribute, composed from other attributes
+	if template := r.urlTemplate(ctx); template != "" {
+		if err := r.checkURLTemplate(template); err != nil {
+			resp.Diagnostics.AddError("Invalid URL template",
+				fmt.Sprintf("Resource %q: %s", r.meta.Name, err))
+			return
+		}
+		s.Attributes[urlAttribute] = tfschema.StringAttribute{
+			Description: fmt.Sprintf("URL of the instance, composed from its attributes as %q.", template),
+			Computed:    true,
+		}
+	}
+
+	// The computed status attribute, unless the resource type has its own
+	if r.hasStatus() {
+		s.Attributes[statusAttribute] = tfschema.StringAttribute{
+			Description: "Runtime status of the instance reported by the server (e.g. \"running\"), or null " +
+				"when the server does not report one.",
+			Computed: true,
+		}
+	}
+
+	// The label attribute, unless the resource type has its own
+	if r.hasLabel() {
+		s.Attributes[labelAttribute] = tfschema.StringAttribute{
+			Description: "Label of the instance, the part of id after the resource type. Generated on create " +
+				"when not set. Changing it replaces the instance, unless the provider sets allow_rename_in_place.",
+			Optional: true,
+			Computed: true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
+			Validators: []validator.String{
+				stringvalidator.LengthAtLeast(1),
+			},
+		}
+	}
+
+	// The computed uuid attribute, unless the resource type has its own
+	if r.hasUUID() {
+		s.Attributes[uuidAttribute] = tfschema.StringAttribute{
+			Description: "Stable identifier assigned to the instance by the server, which unlike id is " +
+				"not derived from its name, or null when the server does not assign one.",
+			Computed: true,
+			PlanModifiers: []planmodifier.String{
+				stringplanmodifier.UseStateForUnknown(),
+			},
+		}
+	}
 	resp.Schema = s
 }
 
-func (r *dynamicResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+func (r *dynamicResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	if req.ProviderData == nil {
 		return
 	}
-	client, ok := req.ProviderData.(*httpclient.Client)
+	data, ok := req.ProviderData.(*providerData)
 	if !ok {
 		resp.Diagnostics.AddError("Unexpected provider data type",
-			fmt.Sprintf("Expected *httpclient.Client, got %T", req.ProviderData))
+			fmt.Sprintf("Expected *providerData, got %T", req.ProviderData))
 		return
 	}
-	r.client = client
+	r.client = data.clientFor(r.meta.Name)
+	r.strict = data.strictConsistency
+	r.refs = data.checkReferences
+	r.force = data.forceDestroy
+	r.delete = data.deleteMode
+	r.lists = data.normalizeLists[r.meta.Name]
+	r.clears = data.clearAttributes[r.meta.Name]
+	r.empties = data.emptyAsNull[r.meta.Name]
+	r.transforms = data.transforms[r.meta.Name]
+	r.status = data.statusAttributes[r.meta.Name]
+	r.times = data.timeFormat
+	r.keyCase = data.keyCase
+	r.staged = data.stagedCreate
+	r.reads = data.batchers[r.client]
+	r.maxBody = data.maxBodySize
+	r.stamps = data.stamps
+	r.managed = data.managedBy
+	r.defs = r.defaultValues(ctx, data.defaultAttributes[r.meta.Name], &resp.Diagnostics)
+	r.ro = data.readOnly
+	r.renames = data.renameInPlace
+	r.deletePoll = data.deletePoll
+	r.deleteTimeout = data.deleteTimeout
+	r.stats = data.stats
+	r.summary = data.summary
 }
 
 // requireClient returns true if the client is available, or adds a diagnostic
@@ -108,142 +350,1207 @@ func (r *dynamicResource) requireClient(diags *diag.Diagnostics) bool {
 	return false
 }
 
+// requireWritable returns true unless the provider is read-only, in which
+// case it adds a diagnostic error naming the operation and returns false.
+// Call at the top of each mutating CRUD method, after requireClient.
+func (r *dynamicResource) requireWritable(op string, diags *diag.Diagnostics) bool {
+	if !r.ro {
+		return true
+	}
+	diags.AddError("Provider is read-only",
+		fmt.Sprintf("Cannot %s a %s instance, as the provider is configured with read_only = true. "+
+			"Unset read_only to make changes.", op, r.meta.Name))
+	return false
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // CRUD
 
 func (r *dynamicResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
-	if !r.requireClient(&resp.Diagnostics) {
+	if !r.requireClient(&resp.Diagnostics) || !r.requireWritable("create", &resp.Diagnostics) {
 		return
 	}
+	defer r.stats.observe(ctx, r.meta.Name, "create")()
+	ctx = withRequestID(withOperationStart(ctx))
+	defer tagDiagnostics(ctx, &resp.Diagnostics)
 
-	label := generateLabel()
+	label := r.plannedLabel(ctx, req.Plan, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	configured := label != ""
+	if !configured {
+		label = generateLabel()
+	}
 	fullName := r.fullName(label)
+	defer r.summary.observe(ctx, r.meta.Name, "create", fullName, &resp.Diagnostics)()
 
-	// Create the instance on the server
-	_, err := r.client.CreateResourceInstance(ctx, schema.CreateResourceInstanceRequest{
-		Name: fullName,
-	})
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to create resource instance", err.Error())
-		return
+	// Never adopt an existing instance, which another configuration may
+	// manage. A generated label is new, so only a configured one is checked.
+	if configured {
+		if _, err := r.client.GetResourceInstance(ctx, fullName); err == nil {
+			resp.Diagnostics.AddError("Resource instance already exists",
+				fmt.Sprintf("Instance %s already exists on the server and is not managed by this state. "+
+					"Import it with \"terraform import\" to manage it here. If it is being replaced with "+
+					"create_before_destroy, change its label, or remove the label so that one is generated.", fullName))
+			return
+		} else if httpStatus(err) != http.StatusNotFound {
+			addClientError(ctx, &resp.Diagnostics, "Failed to check for an existing resource instance", err)
+			return
+		}
 	}
 
-	// Extract desired attributes from the plan and apply them
-	attrs := r.extractAttrs(ctx, req.Plan, &resp.Diagnostics)
+	// Extract desired attributes from the plan
+	attrs := r.extractAttrs(ctx, req.Plan, req.Config, &resp.Diagnostics)
 	if resp.Diagnostics.HasError() {
-		if _, err := r.client.DestroyResourceInstance(ctx, fullName, false); err != nil {
-			resp.Diagnostics.AddWarning("Cleanup failed",
-				fmt.Sprintf("Instance %s was created but attribute extraction failed. "+
-					"Attempted to destroy the instance but cleanup also failed: %s. "+
-					"The instance may need manual removal.", fullName, err))
-		}
+		return
+	}
+	r.emptyAsNull(attrs, true)
+	if r.transformAttrs(attrs, &resp.Diagnostics); resp.Diagnostics.HasError() {
+		return
+	}
+	if r.checkBodySize(fullName, attrs, &resp.Diagnostics); resp.Diagnostics.HasError() {
 		return
 	}
 
-	if len(attrs) > 0 {
-		_, err := r.client.UpdateResourceInstance(ctx, fullName, schema.UpdateResourceInstanceRequest{
-			Attributes: attrs,
-			Apply:      true,
-		})
-		if err != nil {
+	// Create the instance, with its attributes applied in the same request
+	// when staged
+	var staged schema.State
+	if r.staged && len(r.applyPhases(attrs)) <= 1 {
+		staged = attrs
+	}
+	if err := createResourceInstance(ctx, r.client, fullName, staged); err != nil {
+		err = r.redact(err, staged)
+		if !r.createdDespite(ctx, fullName, err) {
+			r.addApplyError(ctx, &resp.Diagnostics, "Failed to create resource instance", err)
+			return
+		}
+		staged = nil // the attributes may not have been applied
+	}
+
+	// Apply the attributes, unless they were applied on create
+	if staged == nil && len(attrs) > 0 {
+		if err := r.applyAttrs(ctx, fullName, attrs, ""); err != nil {
+			err = r.redact(err, attrs)
+			// Destroy without cascade, as nothing can reference the new instance yet
 			if _, cleanupErr := r.client.DestroyResourceInstance(ctx, fullName, false); cleanupErr != nil {
 				resp.Diagnostics.AddWarning("Cleanup failed",
 					fmt.Sprintf("Instance %s was created but applying attributes failed. "+
 						"Attempted to destroy the instance but cleanup also failed: %s. "+
-						"The instance may need manual removal.", fullName, cleanupErr))
+						"The instance may need manual removal, and is listed by the kaiak_orphans data source.", fullName, cleanupErr))
 			}
-			resp.Diagnostics.AddError("Failed to apply attributes", err.Error())
+			r.addApplyError(ctx, &resp.Diagnostics, "Failed to apply attributes", err)
 			return
 		}
 	}
 
 	// Read back the full state from the server
-	r.writeState(ctx, fullName, &resp.State, &resp.Diagnostics, attrs)
+	r.writeState(ctx, fullName, &resp.State, resp.Private, &resp.Diagnostics, attrs)
+	copySettings(ctx, req.Plan, &resp.State, &resp.Diagnostics)
+	setLastApplied(ctx, &resp.State, &resp.Diagnostics)
+	r.presentBlocks(ctx, req.Plan, &resp.State, &resp.Diagnostics)
+	r.canonicalLists(ctx, req.Plan, &resp.State, &resp.Diagnostics)
+	r.emptyCollections(ctx, req.Plan, &resp.State, &resp.Diagnostics)
+	r.emptyStrings(ctx, req.Plan, &resp.State, &resp.Diagnostics)
+	r.transformedStrings(ctx, req.Plan, &resp.State, &resp.Diagnostics)
+	if r.strict && !resp.Diagnostics.HasError() {
+		r.verifyState(ctx, fullName, attrs, &resp.Diagnostics)
+	}
 }
 
 func (r *dynamicResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
 	if !r.requireClient(&resp.Diagnostics) {
 		return
 	}
+	defer r.stats.observe(ctx, r.meta.Name, "read")()
+	ctx = withRequestID(withOperationStart(ctx))
+	defer tagDiagnostics(ctx, &resp.Diagnostics)
 
 	var id types.String
+	var refresh []string
 	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("id"), &id)...)
+	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("refresh_attributes"), &refresh)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	r.writeState(ctx, id.ValueString(), &resp.State, &resp.Diagnostics, nil)
+	r.checkSchemaChanged(ctx, id.ValueString(), req.Private, &resp.Diagnostics)
+	r.writeState(ctx, id.ValueString(), &resp.State, resp.Private, &resp.Diagnostics, nil)
+	r.presentBlocks(ctx, req.State, &resp.State, &resp.Diagnostics)
+	r.canonicalLists(ctx, req.State, &resp.State, &resp.Diagnostics)
+	r.emptyCollections(ctx, req.State, &resp.State, &resp.Diagnostics)
+	r.emptyStrings(ctx, req.State, &resp.State, &resp.Diagnostics)
+	r.transformedStrings(ctx, req.State, &resp.State, &resp.Diagnostics)
+	r.checkManagedBy(ctx, id.ValueString(), req.State, resp.State, &resp.Diagnostics)
+
+	// Attributes not listed in refresh_attributes keep their prior state
+	if len(refresh) > 0 && !resp.Diagnostics.HasError() {
+		r.preserveState(ctx, req.State, &resp.State, refresh, &resp.Diagnostics)
+	}
 }
 
 func (r *dynamicResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	if !r.requireClient(&resp.Diagnostics) {
+	if !r.requireClient(&resp.Diagnostics) || !r.requireWritable("update", &resp.Diagnostics) {
+		return
+	}
+	defer r.stats.observe(ctx, r.meta.Name, "update")()
+	ctx = withRequestID(withOperationStart(ctx))
+	defer tagDiagnostics(ctx, &resp.Diagnostics)
+
+	if r.checkSchemaDrift(ctx, req.Private, &resp.Diagnostics); resp.Diagnostics.HasError() {
 		return
 	}
 
 	var id types.String
+	var mergeBlocks types.Bool
 	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("id"), &id)...)
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("merge_blocks"), &mergeBlocks)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
 	fullName := id.ValueString()
+	defer r.summary.observe(ctx, r.meta.Name, "update", fullName, &resp.Diagnostics)()
+	r.checkSchemaChanged(ctx, fullName, req.Private, &resp.Diagnostics)
+	version := instanceVersion(ctx, req.Private, &resp.Diagnostics)
 
 	// Extract desired attributes and apply them
-	attrs := r.extractAttrs(ctx, req.Plan, &resp.Diagnostics)
+	attrs := r.extractAttrs(ctx, req.Plan, req.Config, &resp.Diagnostics)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	r.emptyAsNull(attrs, false)
+	if r.transformAttrs(attrs, &resp.Diagnostics); resp.Diagnostics.HasError() {
+		return
+	}
 
-	_, err := r.client.UpdateResourceInstance(ctx, fullName, schema.UpdateResourceInstanceRequest{
-		Attributes: attrs,
-		Apply:      true,
-	})
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to update resource instance", err.Error())
+	// Clear attributes which were removed from configuration
+	for _, info := range r.removedAttrs(ctx, req.Config, req.State, &resp.Diagnostics) {
+		attrs[info.kaiakName] = nil
+	}
+
+	// Carry unset block members over from the server, rather than clearing them
+	if mergeBlocks.ValueBool() {
+		if r.mergeBlockAttrs(ctx, fullName, req.Plan, attrs, &resp.Diagnostics); resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if r.checkBodySize(fullName, attrs, &resp.Diagnostics); resp.Diagnostics.HasError() {
 		return
 	}
 
-	r.writeState(ctx, fullName, &resp.State, &resp.Diagnostics, attrs)
+	// Rename the instance when its label changed, once the attributes are
+	// checked, so that they are applied to it under its new name. The new
+	// name goes into state at once, so a later failure cannot lose it.
+	if label := r.plannedLabel(ctx, req.Plan, &resp.Diagnostics); label != "" && r.fullName(label) != fullName {
+		var renamed string
+		if renamed, version = r.rename(ctx, fullName, r.fullName(label), version, &resp.Diagnostics); renamed != fullName {
+			fullName = renamed
+			resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), types.StringValue(fullName))...)
+			resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root(labelAttribute), types.StringValue(label))...)
+		}
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	// A resource type with no writable attributes has nothing to apply
+	if len(attrs) > 0 {
+		if err := r.applyAttrs(ctx, fullName, attrs, version); err != nil {
+			if !addConflictError(&resp.Diagnostics, fullName, version, err) {
+				r.addApplyError(ctx, &resp.Diagnostics, "Failed to update resource instance", r.redact(err, attrs))
+			}
+			return
+		}
+	}
+
+	r.writeState(ctx, fullName, &resp.State, resp.Private, &resp.Diagnostics, attrs)
+	copySettings(ctx, req.Plan, &resp.State, &resp.Diagnostics)
+	setLastApplied(ctx, &resp.State, &resp.Diagnostics)
+	r.presentBlocks(ctx, req.Plan, &resp.State, &resp.Diagnostics)
+	r.canonicalLists(ctx, req.Plan, &resp.State, &resp.Diagnostics)
+	r.emptyCollections(ctx, req.Plan, &resp.State, &resp.Diagnostics)
+	r.emptyStrings(ctx, req.Plan, &resp.State, &resp.Diagnostics)
+	r.transformedStrings(ctx, req.Plan, &resp.State, &resp.Diagnostics)
+	if r.strict && !resp.Diagnostics.HasError() {
+		r.verifyState(ctx, fullName, attrs, &resp.Diagnostics)
+	}
 }
 
 func (r *dynamicResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
-	if !r.requireClient(&resp.Diagnostics) {
+	if !r.requireClient(&resp.Diagnostics) || !r.requireWritable("delete", &resp.Diagnostics) {
 		return
 	}
+	defer r.stats.observe(ctx, r.meta.Name, "delete")()
+	ctx = withRequestID(withOperationStart(ctx))
+	defer tagDiagnostics(ctx, &resp.Diagnostics)
 
 	var id types.String
 	resp.Diagnostics.Append(req.State.GetAttribute(ctx, path.Root("id"), &id)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	defer r.summary.observe(ctx, r.meta.Name, "delete", id.ValueString(), &resp.Diagnostics)()
 
-	_, err := r.client.DestroyResourceInstance(ctx, id.ValueString(), false)
-	if err != nil {
-		resp.Diagnostics.AddError("Failed to destroy resource instance", err.Error())
+	// With force_destroy, the server also destroys dependent instances
+	version := instanceVersion(ctx, req.Private, &resp.Diagnostics)
+	if err := destroyResourceInstance(ctx, r.client, id.ValueString(), r.force, r.delete, version); httpStatus(err) == http.StatusNotFound {
+		// Already destroyed, for example by hand, which is the state wanted
+		logInfo(ctx, "Resource instance already destroyed", map[string]interface{}{
+			"id": id.ValueString(),
+		})
+		resp.State.RemoveResource(ctx)
+		return
+	} else if err != nil {
+		if !addConflictError(&resp.Diagnostics, id.ValueString(), version, err) {
+			addClientError(ctx, &resp.Diagnostics, "Failed to destroy resource instance", err)
+		}
 		return
 	}
+	if r.delete == deleteModeRetain {
+		logInfo(ctx, "Resource instance retained by the server for recovery", map[string]interface{}{
+			"id": id.ValueString(),
+		})
+	}
+
+	// Wait for asynchronous teardown, so a dependent cannot race a recreate
+	if r.deletePoll > 0 {
+		if err := r.waitDestroyed(ctx, id.ValueString()); errors.Is(err, errPollTimeout) && ctx.Err() == nil {
+			resp.Diagnostics.AddError("Failed waiting for resource instance to be destroyed",
+				fmt.Sprintf("Instance %s still exists %s after it was destroyed, which exceeds the provider delete_timeout.",
+					id.ValueString(), r.deleteTimeout))
+			return
+		} else if err != nil {
+			addClientError(ctx, &resp.Diagnostics, "Failed waiting for resource instance to be destroyed", err)
+			return
+		}
+	}
 
 	resp.State.RemoveResource(ctx)
 }
 
+// applyAttrs sets and applies the attributes of an instance, in a request
+// for each position in the server's apply order. When a version is given,
+// the first request is rejected if the instance has since been modified.
+func (r *dynamicResource) applyAttrs(ctx context.Context, fullName string, attrs schema.State, version string) error {
+	phases := r.applyPhases(attrs)
+	for i, phase := range phases {
+		if len(phases) > 1 {
+			logDebug(ctx, "Applying attributes in order", map[string]interface{}{
+				"instance": fullName,
+				"phase":    i + 1,
+				"phases":   len(phases),
+			})
+		}
+		if err := updateResourceInstance(ctx, r.client, fullName, schema.UpdateResourceInstanceRequest{
+			Attributes: phase,
+			Apply:      true,
+		}, version); err != nil {
+			return err
+		}
+		version = "" // later phases follow the first
+	}
+	return nil
+}
+
+// applyPhases splits attributes by their position in the apply order, in
+// ascending order, where an attribute without a position has position zero.
+func (r *dynamicResource) applyPhases(attrs schema.State) []schema.State {
+	order := make(map[string]int, len(r.infos))
+	for _, info := range r.infos {
+		order[info.kaiakName] = info.attr.ApplyOrder
+	}
+	byOrder := map[int]schema.State{}
+	for name, value := range attrs {
+		if byOrder[order[name]] == nil {
+			byOrder[order[name]] = schema.State{}
+		}
+		byOrder[order[name]][name] = value
+	}
+	phases := make([]schema.State, 0, len(byOrder))
+	for _, n := range slices.Sorted(maps.Keys(byOrder)) {
+		phases = append(phases, byOrder[n])
+	}
+	return phases
+}
+
+// waitDestroyed polls the server until the instance is no longer found, or
+// the delete timeout elapses.
+func (r *dynamicResource) waitDestroyed(ctx context.Context, fullName string) error {
+	ctx, cancel := context.WithTimeout(ctx, r.deleteTimeout)
+	defer cancel()
+	return poll(ctx, r.deletePoll, func(ctx context.Context) (bool, error) {
+		_, err := r.client.GetResourceInstance(ctx, fullName)
+		switch {
+		case err == nil:
+			return false, nil
+		case httpStatus(err) == http.StatusNotFound:
+			return true, nil
+		case ctx.Err() != nil:
+			return false, nil // reported by poll as a timeout or cancellation
+		default:
+			return false, err
+		}
+	})
+}
+
 func (r *dynamicResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	// Import by fully qualified name (e.g. "httpstatic.docs").
-	parts := strings.SplitN(req.ID, ".", 2)
-	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
-		resp.Diagnostics.AddError("Invalid import ID",
-			fmt.Sprintf("Expected format \"resource_type.label\" (e.g. \"httpstatic.docs\"), got %q", req.ID))
+	// Import by fully qualified name (e.g. "httpstatic.docs"), followed by
+	// the values of any address attributes (e.g. "httpstatic.docs/prod")
+	resourceType, label, err := parseInstanceName(req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import ID", err.Error())
+		return
+	}
+	addrs := r.addressAttrs()
+	var values []string
+	if len(addrs) > 0 && label != "*" {
+		parts := strings.Split(label, "/")
+		if len(parts) != len(addrs)+1 || slices.Contains(parts, "") {
+			resp.Diagnostics.AddError("Invalid import ID",
+				fmt.Sprintf("Import ID %q must have the form %q, as instances of kaiak_%s are addressed by their label and %s.",
+					req.ID, r.importFormat(addrs), r.meta.Name, describeAddress(addrs)))
+			return
+		}
+		label, values = parts[0], parts[1:]
+	}
+
+	if resourceType != r.meta.Name {
+		resp.Diagnostics.AddError("Resource type mismatch",
+			fmt.Sprintf("Import ID %q has resource type %q, but this resource block is kaiak_%s. "+
+				"Use the matching resource type or correct the import ID.", req.ID, resourceType, r.meta.Name))
+		return
+	}
+
+	if !r.requireClient(&resp.Diagnostics) {
+		return
+	}
+
+	// A wildcard lists the instances which can be imported
+	if label == "*" {
+		labels, err := r.instanceLabels(ctx)
+		if err != nil {
+			addClientError(ctx, &resp.Diagnostics, "Failed to list resource instances", err)
+			return
+		}
+		resp.Diagnostics.AddError("Wildcard import ID",
+			fmt.Sprintf("Import ID %q matches %s. Import each instance separately, for example:\n\n"+
+				"  terraform import kaiak_%s.<name> %s", req.ID, describeLabels(labels), r.meta.Name, r.importFormat(addrs)))
+		return
+	}
+
+	// Look up the instance so state holds the server's canonical name,
+	// which may differ from the import ID (e.g. in casing)
+	result, err := r.client.GetResourceInstance(ctx, resourceType+"."+label)
+	if err != nil {
+		detail := clientErrorDetail(ctx, err)
+		if httpStatus(err) == http.StatusNotFound {
+			if labels, err := r.instanceLabels(ctx); err == nil {
+				detail += fmt.Sprintf("\n\nImport ID %q matches no instance. The server has %s.", req.ID, describeLabels(labels))
+			}
+		}
+		resp.Diagnostics.AddError("Failed to read resource instance for import", detail)
+		return
+	}
+	if canonicalType, _, err := parseInstanceName(result.Instance.Name); err != nil || canonicalType != r.meta.Name {
+		resp.Diagnostics.AddError("Unexpected instance name",
+			fmt.Sprintf("The server returned instance name %q for import ID %q, which is not a kaiak_%s instance.",
+				result.Instance.Name, req.ID, r.meta.Name))
+		return
+	}
+
+	// The instance must be at the address given, which is set in state
+	for i, info := range addrs {
+		if v, ok := r.instanceState(result.Instance.State)[info.kaiakName]; ok && v != nil && fmt.Sprint(v) != values[i] {
+			detail := fmt.Sprintf("Import ID %q does not match the %q of instance %s", req.ID, info.kaiakName, result.Instance.Name)
+			if !info.attr.Sensitive {
+				detail += fmt.Sprintf(", which is %q", fmt.Sprint(v))
+			}
+			resp.Diagnostics.AddError("Instance address mismatch", detail+".")
+			return
+		}
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, info.path(), kaiakValueToTF(ctx, values[i], info.attr.Type, info.attr.Sensitive))...)
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), result.Instance.Name)...)
+}
+
+// addressAttrs returns the attributes which address an instance together
+// with its label, in the order they follow the label in an import ID.
+func (r *dynamicResource) addressAttrs() []attrInfo {
+	var addrs []attrInfo
+	for _, info := range r.getInfos() {
+		if info.attr.Address && !info.alias {
+			addrs = append(addrs, info)
+		}
+	}
+	return addrs
+}
+
+// importFormat returns the form of an import ID for the resource type, with
+// a placeholder for the label and the value of each address attribute.
+func (r *dynamicResource) importFormat(addrs []attrInfo) string {
+	format := r.meta.Name + ".<label>"
+	for _, info := range addrs {
+		format += "/<" + info.kaiakName + ">"
+	}
+	return format
+}
+
+// describeAddress describes the address attributes for a diagnostic.
+func describeAddress(addrs []attrInfo) string {
+	names := make([]string, len(addrs))
+	for i, info := range addrs {
+		names[i] = strconv.Quote(info.kaiakName)
+	}
+	return strings.Join(names, ", ")
+}
+
+// instanceLabels returns the sorted labels of the existing instances of the
+// resource type on the server.
+func (r *dynamicResource) instanceLabels(ctx context.Context) ([]string, error) {
+	name := r.meta.Name
+	result, err := r.client.ListResources(ctx, schema.ListResourcesRequest{Type: &name})
+	if err != nil {
+		return nil, err
+	}
+	var labels []string
+	for _, res := range result.Resources {
+		for _, instance := range res.Instances {
+			if resourceType, label, err := parseInstanceName(instance.Name); err == nil && resourceType == name {
+				labels = append(labels, label)
+			}
+		}
+	}
+	sort.Strings(labels)
+	return labels, nil
+}
+
+// describeLabels describes the instance labels for a diagnostic.
+func describeLabels(labels []string) string {
+	if len(labels) == 0 {
+		return "no instances"
+	}
+	return fmt.Sprintf("%d instance(s) with labels: %s", len(labels), strings.Join(labels, ", "))
+}
+
+// ConfigValidators returns validators for relationships between attributes
+// (required together, mutually exclusive) expressed in the server metadata.
+func (r *dynamicResource) ConfigValidators(_ context.Context) []resource.ConfigValidator {
+	return relationValidators(r.getInfos())
+}
+
+func (r *dynamicResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to check when destroying
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+
+	// Record the schema the plan was made against, so apply can detect drift
+	if data, err := json.Marshal(r.meta.hash()); err == nil {
+		resp.Diagnostics.Append(resp.Private.SetKey(ctx, schemaHashKey, data)...)
+	}
+
+	// Attributes removed from configuration which can be cleared would
+	// otherwise keep their prior state, as they are computed
+	if !req.State.Raw.IsNull() {
+		r.planClears(ctx, req.Config, req.State, &resp.Plan, &resp.Diagnostics)
+	}
+
+	// A changed label replaces the instance, or renames it in place
+	if !req.State.Raw.IsNull() && r.hasLabel() {
+		r.planLabel(ctx, req.State, resp, &resp.Diagnostics)
+	}
+
+	// Attributes not set in configuration take the provider's defaults
+	if len(r.defs) > 0 {
+		r.planDefaults(ctx, req.Config, &resp.Plan, &resp.Diagnostics)
+	}
+
+	// Stamp instances which are being created or updated with workspace metadata
+	if len(r.stamps) > 0 && (req.State.Raw.IsNull() || !resp.Plan.Raw.Equal(req.State.Raw)) {
+		r.planStamps(ctx, req.Config, &resp.Plan, &resp.Diagnostics)
+	}
+
+	// Renamed attributes and their aliases are planned with the same value
+	r.planAliases(ctx, req.Config, &resp.Plan, &resp.Diagnostics)
+
+	// Nothing more to check before the provider is configured
+	if r.client == nil {
+		return
+	}
+	if r.refs {
+		r.checkReferences(ctx, req.Plan, &resp.Diagnostics)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE — clearing attributes
+
+// clearable returns true if an optional attribute is cleared on the server
+// when removed from configuration, as declared by the server metadata or
+// the clear_attributes provider setting.
+func (r *dynamicResource) clearable(info attrInfo) bool {
+	if info.attr.Required || info.attr.ReadOnly || info.alias {
+		return false
+	}
+	return info.attr.Clearable || slices.Contains(r.clears, info.kaiakName)
+}
+
+// removedAttrs returns the clearable attributes which are null in config
+// but not in the prior state, because they were removed from configuration.
+func (r *dynamicResource) removedAttrs(ctx context.Context, config, prior attrGetter, diags *diag.Diagnostics) []attrInfo {
+	var removed []attrInfo
+	for _, info := range r.getInfos() {
+		if !r.clearable(info) {
+			continue
+		}
+		var configured, previous attr.Value
+		diags.Append(config.GetAttribute(ctx, info.path(), &configured)...)
+		diags.Append(prior.GetAttribute(ctx, info.path(), &previous)...)
+		if configured == nil || previous == nil || !configured.IsNull() || previous.IsNull() || previous.IsUnknown() {
+			continue
+		}
+		removed = append(removed, info)
+	}
+	return removed
+}
+
+// planClears marks each attribute removed from configuration as unknown in
+// the plan, so that an update is planned which clears it on the server.
+func (r *dynamicResource) planClears(ctx context.Context, config, prior attrGetter, plan *tfsdk.Plan, diags *diag.Diagnostics) {
+	for _, info := range r.removedAttrs(ctx, config, prior, diags) {
+		var v attr.Value
+		diags.Append(plan.GetAttribute(ctx, info.path(), &v)...)
+		if v == nil || v.IsUnknown() {
+			continue
+		}
+		t := v.Type(ctx)
+		unknown, err := t.ValueFromTerraform(ctx, tftypes.NewValue(t.TerraformType(ctx), tftypes.UnknownValue))
+		if err != nil {
+			diags.AddAttributeError(info.path(), "Failed to plan clearing attribute", err.Error())
+			continue
+		}
+		diags.Append(plan.SetAttribute(ctx, info.path(), unknown)...)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE — attribute aliases
+
+// planAliases plans each renamed attribute and its aliases with the same
+// value: the value of an alias when it is set in configuration, or else the
+// planned value of the attribute. Only the attribute itself is sent to the
+// server, and the server value is written to both in state.
+func (r *dynamicResource) planAliases(ctx context.Context, config attrGetter, plan *tfsdk.Plan, diags *diag.Diagnostics) {
+	targets := map[string]attrInfo{}
+	for _, info := range r.getInfos() {
+		if !info.alias {
+			targets[info.kaiakName] = info
+		}
+	}
+
+	// Values are only set where they differ, so that a block which is null
+	// in the plan is not set to an object of nulls
+	set := func(p path.Path, v attr.Value) {
+		var current attr.Value
+		diags.Append(plan.GetAttribute(ctx, p, &current)...)
+		if v != nil && (current == nil || !current.Equal(v)) {
+			diags.Append(plan.SetAttribute(ctx, p, v)...)
+		}
+	}
+
+	// An alias set in configuration takes the place of the attribute
+	for _, info := range r.getInfos() {
+		if !info.alias {
+			continue
+		}
+		var v attr.Value
+		diags.Append(config.GetAttribute(ctx, info.path(), &v)...)
+		if v != nil && !v.IsNull() {
+			diags.Append(plan.GetAttribute(ctx, info.path(), &v)...)
+			set(targets[info.kaiakName].path(), v)
+		}
+	}
+
+	// Every alias follows the attribute
+	for _, info := range r.getInfos() {
+		if !info.alias {
+			continue
+		}
+		var v attr.Value
+		diags.Append(plan.GetAttribute(ctx, targets[info.kaiakName].path(), &v)...)
+		set(info.path(), v)
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE — workspace metadata
+
+// planStamps sets each workspace metadata attribute which is not set in
+// configuration to its value in the plan, so that it is sent to the server.
+// Attributes which the resource type does not have as a writable top-level
+// string are skipped, with a warning logged once per resource type.
+func (r *dynamicResource) planStamps(ctx context.Context, config attrGetter, plan *tfsdk.Plan, diags *diag.Diagnostics) {
+	for name, value := range r.stamps {
+		i := slices.IndexFunc(r.getInfos(), func(info attrInfo) bool {
+			return info.kaiakName == name && info.tfBlock == "" && !info.attr.ReadOnly && !info.attr.WriteOnly && !info.alias && info.attr.Type == "string"
+		})
+		if i < 0 {
+			if _, warned := warnedStamps.LoadOrStore(r.meta.Name+"/"+name, true); !warned {
+				logWarn(ctx, "Resource type has no writable string attribute for workspace metadata", map[string]interface{}{
+					"resource":  r.meta.Name,
+					"attribute": name,
+				})
+			}
+			continue
+		}
+
+		info := r.getInfos()[i]
+		var configured attr.Value
+		diags.Append(config.GetAttribute(ctx, info.path(), &configured)...)
+		if configured != nil && configured.IsNull() {
+			diags.Append(plan.SetAttribute(ctx, info.path(), types.StringValue(value))...)
+		}
+	}
+}
+
+// checkManagedBy adds a warning when an instance which was stamped with the
+// managed-by marker no longer has it on the server, a sign that it was
+// modified outside Terraform. Instances whose prior state does not hold the
+// marker, such as those with another value set in configuration, are not
+// checked.
+func (r *dynamicResource) checkManagedBy(ctx context.Context, fullName string, prior, refreshed tfsdk.State, diags *diag.Diagnostics) {
+	if r.managed == "" || !slices.ContainsFunc(r.getInfos(), func(info attrInfo) bool {
+		return info.kaiakName == r.managed && info.tfBlock == "" && !info.alias && info.attr.Type == "string"
+	}) {
+		return
+	}
+	var was, now types.String
+	diags.Append(prior.GetAttribute(ctx, path.Root(r.managed), &was)...)
+	diags.Append(refreshed.GetAttribute(ctx, path.Root(r.managed), &now)...)
+	if was.ValueString() != managedByMarker || now.ValueString() == managedByMarker {
 		return
 	}
+	found := "no longer has it"
+	if !now.IsNull() {
+		found = fmt.Sprintf("now has %q", now.ValueString())
+	}
+	diags.AddAttributeWarning(path.Root(r.managed), "Instance modified outside Terraform",
+		fmt.Sprintf("Instance %s was stamped with %s = %q when Terraform last applied it, but %s. The instance "+
+			"may have been modified outside Terraform, so review it before applying. The marker is stamped again "+
+			"the next time Terraform updates the instance.",
+			fullName, r.managed, managedByMarker, found))
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE — default attributes
+
+// defaultValues parses the default_attributes of the resource type into
+// values of each attribute's type. A string attribute takes the string as
+// it is, a scalar attribute its parsed value (e.g. "8080" or "true"), and a
+// list or map attribute the JSON encoding of its value. An error is added
+// for an attribute which the resource type does not have as a writable
+// attribute, or a value which cannot be parsed.
+func (r *dynamicResource) defaultValues(ctx context.Context, defaults map[string]string, diags *diag.Diagnostics) map[string]attr.Value {
+	values := make(map[string]attr.Value, len(defaults))
+	for name, s := range defaults {
+		i := slices.IndexFunc(r.getInfos(), func(info attrInfo) bool {
+			return info.kaiakName == name && !info.attr.ReadOnly && !info.alias
+		})
+		if i < 0 {
+			diags.AddAttributeError(path.Root("default_attributes").AtMapKey(r.meta.Name).AtMapKey(name),
+				"Invalid default attribute",
+				fmt.Sprintf("Resource type %q has no writable attribute %q.", r.meta.Name, name))
+			continue
+		}
+		info := r.getInfos()[i]
+		if v, ok := defaultValue(ctx, info.attr.Type, s); ok {
+			values[name] = v
+		} else {
+			diags.AddAttributeError(path.Root("default_attributes").AtMapKey(r.meta.Name).AtMapKey(name),
+				"Invalid default attribute",
+				fmt.Sprintf("The default %q for attribute %q of resource type %q is not a valid %q value.",
+					s, name, r.meta.Name, info.attr.Type))
+		}
+	}
+	return values
+}
+
+// defaultValue parses a default value for an attribute of kaiak type t,
+// returning false if it is not a valid value of the type.
+func defaultValue(ctx context.Context, t, s string) (attr.Value, bool) {
+	if !strings.HasPrefix(t, "[]") && !strings.HasPrefix(t, "map[") {
+		return kaiakCoerce(s, t)
+	}
+	var v any
+	if err := json.Unmarshal([]byte(s), &v); err != nil || v == nil || !kaiakValueMatches(v, t) {
+		return nil, false
+	}
+	return kaiakValueToTF(ctx, v, t, false), true
+}
+
+// planDefaults sets each attribute with a default which is not set in
+// configuration to the default in the plan, so that it is sent to the
+// server. Members of a block which is not set in configuration are left
+// unset.
+func (r *dynamicResource) planDefaults(ctx context.Context, config attrGetter, plan *tfsdk.Plan, diags *diag.Diagnostics) {
+	for _, info := range r.getInfos() {
+		v, ok := r.defs[info.kaiakName]
+		if !ok || info.alias || info.attr.WriteOnly {
+			continue
+		}
+		if info.tfBlock != "" {
+			var block attr.Value
+			diags.Append(config.GetAttribute(ctx, blockPath(info.tfBlock), &block)...)
+			if block == nil || block.IsNull() {
+				continue
+			}
+		}
+		var configured attr.Value
+		diags.Append(config.GetAttribute(ctx, info.path(), &configured)...)
+		if configured != nil && configured.IsNull() {
+			diags.Append(plan.SetAttribute(ctx, info.path(), v)...)
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE — instance URL
+
+// urlTemplate returns the template for the computed url attribute, or an
+// empty string when there is none. A resource type with its own url
+// attribute keeps it, and the template is ignored.
+func (r *dynamicResource) urlTemplate(ctx context.Context) string {
+	if r.meta.URLTemplate == "" {
+		return ""
+	}
+	for _, info := range r.getInfos() {
+		if info.tfBlock == "" && info.tfField == urlAttribute {
+			if _, warned := warnedURLs.LoadOrStore(r.meta.Name, true); !warned {
+				logWarn(ctx, "Resource type has its own url attribute: ignoring URL template", map[string]interface{}{
+					"resource": r.meta.Name,
+				})
+			}
+			return ""
+		}
+	}
+	return r.meta.URLTemplate
+}
+
+// checkURLTemplate returns an error if the template names an attribute which
+// the resource type does not have, or a sensitive attribute, which would
+// be revealed in the url.
+func (r *dynamicResource) checkURLTemplate(template string) error {
+	for _, match := range urlPlaceholder.FindAllStringSubmatch(template, -1) {
+		i := slices.IndexFunc(r.meta.Attributes, func(a attributeMeta) bool {
+			return a.Name == match[1]
+		})
+		switch {
+		case i < 0:
+			return fmt.Errorf("URL template %q names unknown attribute %q", template, match[1])
+		case r.meta.Attributes[i].Sensitive:
+			return fmt.Errorf("URL template %q names sensitive attribute %q", template, match[1])
+		}
+	}
+	return nil
+}
+
+// composeURL returns the url composed from the template and the attributes
+// of an instance, or null when any attribute named in the template is unset.
+func composeURL(template string, attrs schema.State) types.String {
+	missing := false
+	url := urlPlaceholder.ReplaceAllStringFunc(template, func(match string) string {
+		switch v := attrs[match[1:len(match)-1]].(type) {
+		case nil:
+			missing = true
+			return ""
+		case string:
+			return v
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return fmt.Sprintf("%v", v)
+		}
+	})
+	if missing {
+		return types.StringNull()
+	}
+	return types.StringValue(url)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE — instance status
+
+// hasStatus returns true if the resource has the computed status attribute,
+// which is the case unless the resource type has its own status attribute.
+func (r *dynamicResource) hasStatus() bool {
+	return !slices.ContainsFunc(r.getInfos(), func(info attrInfo) bool {
+		return info.tfBlock == "" && info.tfField == statusAttribute
+	})
+}
+
+// instanceStatus returns the runtime status of an instance: the value of
+// the configured key of its state, or else the status the server reports
+// alongside the instance, or else null.
+func (r *dynamicResource) instanceStatus(result *getResourceInstanceResponse, state schema.State) types.String {
+	if r.status == "" {
+		if result.Status == "" {
+			return types.StringNull()
+		}
+		return types.StringValue(result.Status)
+	}
+	switch v := state[r.status].(type) {
+	case nil:
+		return types.StringNull()
+	case string:
+		return types.StringValue(v)
+	default:
+		return types.StringValue(jsonString(v))
+	}
+}
+
+// hasLabel returns true if the resource has the label attribute, which it
+// does unless the resource type has an attribute of the same name.
+func (r *dynamicResource) hasLabel() bool {
+	return !slices.ContainsFunc(r.getInfos(), func(info attrInfo) bool {
+		return info.tfBlock == "" && info.tfField == labelAttribute
+	})
+}
+
+// plannedLabel returns the label of the instance in the plan, or an empty
+// string when the resource has no label attribute or it is not yet known.
+func (r *dynamicResource) plannedLabel(ctx context.Context, plan attrGetter, diags *diag.Diagnostics) string {
+	if !r.hasLabel() {
+		return ""
+	}
+	var label types.String
+	diags.Append(plan.GetAttribute(ctx, path.Root(labelAttribute), &label)...)
+	return label.ValueString()
+}
+
+// planLabel plans a replacement of the instance when its label changes, or
+// with allow_rename_in_place, a rename in place, with the new id.
+func (r *dynamicResource) planLabel(ctx context.Context, prior attrGetter, resp *resource.ModifyPlanResponse, diags *diag.Diagnostics) {
+	var was, now types.String
+	diags.Append(prior.GetAttribute(ctx, path.Root(labelAttribute), &was)...)
+	diags.Append(resp.Plan.GetAttribute(ctx, path.Root(labelAttribute), &now)...)
+	if was.IsNull() || now.Equal(was) {
+		return
+	}
+	switch {
+	case !r.renames:
+		resp.RequiresReplace = append(resp.RequiresReplace, path.Root(labelAttribute))
+	case now.IsUnknown():
+		diags.Append(resp.Plan.SetAttribute(ctx, path.Root("id"), types.StringUnknown())...)
+	default:
+		diags.Append(resp.Plan.SetAttribute(ctx, path.Root("id"), types.StringValue(r.fullName(now.ValueString())))...)
+	}
+}
+
+// rename renames an instance in place, and returns its new name and
+// version, which changes with the rename.
+func (r *dynamicResource) rename(ctx context.Context, fullName, newName, version string, diags *diag.Diagnostics) (string, string) {
+	if err := renameResourceInstance(ctx, r.client, fullName, newName, version); err != nil {
+		if !addConflictError(diags, fullName, version, err) {
+			addClientError(ctx, diags, "Failed to rename resource instance", err)
+		}
+		return fullName, version
+	}
+	logInfo(ctx, "Renamed resource instance", map[string]interface{}{
+		"instance": fullName,
+		"name":     newName,
+	})
+	result, err := r.getInstance(ctx, newName)
+	if err != nil {
+		addClientError(ctx, diags, "Failed to read renamed resource instance", err)
+		return newName, ""
+	}
+	return newName, result.Version
+}
+
+// hasUUID returns true if the resource has the computed uuid attribute,
+// which is the case unless the resource type has its own uuid attribute.
+func (r *dynamicResource) hasUUID() bool {
+	return !slices.ContainsFunc(r.getInfos(), func(info attrInfo) bool {
+		return info.tfBlock == "" && info.tfField == uuidAttribute
+	})
+}
+
+// instanceUUID returns the identifier the server assigned to an instance, or
+// null when it assigns none.
+func instanceUUID(result *getResourceInstanceResponse) types.String {
+	if result.UUID == "" {
+		return types.StringNull()
+	}
+	return types.StringValue(result.UUID)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE — schema drift
+
+// checkSchemaDrift adds an error if the resource schema discovered for this
+// apply differs from the one recorded in private state at plan time. Plans
+// made by older provider versions carry no hash and are not checked. The
+// framework does not pass planned private state to Create, so only updates
+// can be checked.
+func (r *dynamicResource) checkSchemaDrift(ctx context.Context, private privateGetter, diags *diag.Diagnostics) {
+	data, d := private.GetKey(ctx, schemaHashKey)
+	diags.Append(d...)
+
+	var planned string
+	if len(data) == 0 || json.Unmarshal(data, &planned) != nil {
+		return
+	}
+	if planned != r.meta.hash() {
+		diags.AddError("Resource schema drifted",
+			fmt.Sprintf("The schema of resource type %q discovered from the server changed between plan and apply. "+
+				"Re-run plan against the current server and apply again.", r.meta.Name))
+	}
+}
+
+// checkSchemaChanged adds a warning if the resource schema discovered from
+// the server differs from the one recorded in private state when the
+// instance was last applied or refreshed, so that a changed schema is
+// reported rather than surfacing as unexplained differences. Instances
+// recorded by older provider versions carry no hash and are not checked.
+func (r *dynamicResource) checkSchemaChanged(ctx context.Context, fullName string, private privateGetter, diags *diag.Diagnostics) {
+	data, d := private.GetKey(ctx, appliedSchemaKey)
+	diags.Append(d...)
+
+	var applied string
+	if len(data) == 0 || json.Unmarshal(data, &applied) != nil {
+		return
+	}
+	if applied != r.meta.hash() {
+		diags.AddWarning("Resource schema changed",
+			fmt.Sprintf("The schema of resource type %q discovered from the server has changed since instance %s "+
+				"was last applied, for example because the server was upgraded. Attributes which were added, "+
+				"removed or changed may show as differences in the plan. Review the plan, and update the "+
+				"configuration to the new schema where needed. Applying records the new schema, after which "+
+				"this warning is no longer shown.", r.meta.Name, fullName))
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE — concurrent modification
+
+// instanceVersion returns the version of the instance recorded in private
+// state when it was last read, or empty if the server reports none.
+func instanceVersion(ctx context.Context, private privateGetter, diags *diag.Diagnostics) string {
+	data, d := private.GetKey(ctx, versionKey)
+	diags.Append(d...)
+
+	var version string
+	if len(data) > 0 {
+		_ = json.Unmarshal(data, &version)
+	}
+	return version
+}
+
+// addConflictError adds an error for an update or destroy which the server
+// rejected because the instance was modified since Terraform last read it,
+// and returns false for any other error.
+func addConflictError(diags *diag.Diagnostics, fullName, version string, err error) bool {
+	if status := httpStatus(err); version == "" || (status != http.StatusConflict && status != http.StatusPreconditionFailed) {
+		return false
+	}
+	diags.AddError("Resource instance modified concurrently",
+		fmt.Sprintf("Instance %s was modified on the server since Terraform last read it at version %s, for example "+
+			"by another Terraform run or by hand, so the change was not made. Run \"terraform plan\" to review "+
+			"the instance as it is now, then apply again. (%s)", fullName, version, err))
+	return true
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE — server errors
+
+// addApplyError adds an error for a failed apply. When the operation was
+// canceled or timed out, the error says so. When the server rejected
+// a specific attribute, the error is attached to that attribute, so it is
+// reported at the right line in the configuration.
+func (r *dynamicResource) addApplyError(ctx context.Context, diags *diag.Diagnostics, summary string, err error) {
+	if ctx.Err() != nil {
+		addClientError(ctx, diags, summary, err)
+	} else if info, ok := r.errorAttr(err); ok {
+		diags.AddAttributeError(info.path(), summary, err.Error())
+	} else {
+		diags.AddError(summary, err.Error())
+	}
+}
+
+// addClientError adds an error for a failed request to the server, which
+// distinguishes a canceled or timed out operation from an error returned by
+// the server.
+func addClientError(ctx context.Context, diags *diag.Diagnostics, summary string, err error) {
+	diags.AddError(summary, clientErrorDetail(ctx, err))
+}
+
+// clientErrorDetail returns the detail of an error from a request to the
+// server. When the operation's context is done, it says that the operation
+// was canceled or timed out, and after how long, rather than reporting the
+// bare context error as if the server had returned it.
+func clientErrorDetail(ctx context.Context, err error) string {
+	cause := ctx.Err()
+	if cause == nil {
+		return err.Error()
+	}
+	var elapsed string
+	if start, ok := ctx.Value(operationStartKey{}).(time.Time); ok {
+		elapsed = " after " + time.Since(start).Round(time.Millisecond).String()
+	}
+	what := "was canceled" + elapsed + ", for example because Terraform was interrupted,"
+	if errors.Is(cause, context.DeadlineExceeded) {
+		what = "timed out" + elapsed
+	}
+	return fmt.Sprintf("The operation %s before the server responded, so any change may or may not have been "+
+		"made on the server. Run \"terraform plan\" to check the instance. (%s)", what, err)
+}
+
+// withOperationStart returns a context which records the start of a CRUD
+// operation, for reporting how long it ran before being canceled.
+func withOperationStart(ctx context.Context) context.Context {
+	return context.WithValue(ctx, operationStartKey{}, time.Now())
+}
+
+// errorAttr returns the attribute named by a validation error from the
+// server, either as "attribute" or "field" in the error detail, or quoted
+// in the reason. It returns false when no known attribute is named.
+func (r *dynamicResource) errorAttr(err error) (attrInfo, bool) {
+	if httpStatus(err) != http.StatusBadRequest {
+		return attrInfo{}, false
+	}
+
+	// The response body follows the status in the error message
+	msg := err.Error()
+	i := strings.Index(msg, "{")
+	if i < 0 {
+		return attrInfo{}, false
+	}
+	var body serverError
+	if json.Unmarshal([]byte(msg[i:]), &body) != nil {
+		return attrInfo{}, false
+	}
+
+	var names []string
+	if detail, ok := body.Detail.(map[string]any); ok {
+		for _, key := range []string{"attribute", "field"} {
+			if name, ok := detail[key].(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	for _, info := range r.getInfos() {
+		if slices.Contains(names, info.kaiakName) || strings.Contains(body.Reason, strconv.Quote(info.kaiakName)) {
+			return info, true
+		}
+	}
+	return attrInfo{}, false
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE — sensitive values
+
+// redact returns the error with the values of sensitive attributes which
+// were sent masked in its message, as a server error may echo back the
+// request. The returned error wraps the original, so its HTTP status is
+// kept. Values shorter than minRedactLength are not masked, as they would
+// mask unrelated text.
+func (r *dynamicResource) redact(err error, sent schema.State) error {
+	var secrets []string
+	for _, info := range r.getInfos() {
+		if info.attr.Sensitive && !info.alias {
+			secrets = appendSecrets(secrets, sent[info.kaiakName])
+		}
+	}
+	if len(secrets) == 0 {
+		return err
+	}
+
+	// Mask longer values first, so a value containing another is masked whole
+	slices.SortFunc(secrets, func(a, b string) int {
+		return cmp.Compare(len(b), len(a))
+	})
+	msg := err.Error()
+	for _, secret := range secrets {
+		msg = strings.ReplaceAll(msg, secret, redactedValue)
+	}
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{msg: msg, err: err}
+}
+
+// appendSecrets appends the string forms of a sensitive value, and of each
+// element of a list or map, as they may appear in a JSON error body.
+func appendSecrets(secrets []string, v any) []string {
+	switch v := v.(type) {
+	case string:
+		if len(v) >= minRedactLength {
+			secrets = append(secrets, v)
+			if quoted := strings.Trim(jsonString(v), `"`); quoted != v {
+				secrets = append(secrets, quoted)
+			}
+		}
+	case []string:
+		for _, item := range v {
+			secrets = appendSecrets(secrets, item)
+		}
+	case []any:
+		for _, item := range v {
+			secrets = appendSecrets(secrets, item)
+		}
+	case map[string]any:
+		for _, item := range v {
+			secrets = appendSecrets(secrets, item)
+		}
+	}
+	return secrets
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE — reference checks
 
-	if parts[0] != r.meta.Name {
-		resp.Diagnostics.AddError("Resource type mismatch",
-			fmt.Sprintf("Import ID %q has resource type %q, but this resource block is kaiak_%s. "+
-				"Use the matching resource type or correct the import ID.", req.ID, parts[0], r.meta.Name))
-		return
+// checkReferences looks up every known reference value in the plan and
+// adds a warning for each referenced instance which does not (yet) exist
+// on the server. Unknown values, such as the id of an instance created in
+// the same apply, are skipped.
+func (r *dynamicResource) checkReferences(ctx context.Context, plan tfsdk.Plan, diags *diag.Diagnostics) {
+	var ignored diag.Diagnostics
+	planned := r.extractAttrs(ctx, plan, nil, &ignored)
+	for _, info := range r.getInfos() {
+		if info.alias {
+			continue
+		}
+		var names []string
+		switch v := planned[info.kaiakName].(type) {
+		case string:
+			if info.attr.Type == "ref" {
+				names = append(names, v)
+			}
+		case []string:
+			if info.attr.Type == "[]ref" {
+				names = append(names, v...)
+			}
+		}
+		for _, name := range names {
+			if _, err := r.client.GetResourceInstance(ctx, name); err != nil {
+				diags.AddAttributeWarning(info.path(), "Referenced instance not found",
+					fmt.Sprintf("Attribute %q references %q, which could not be read from the server: %s. "+
+						"Check the reference for typos if it is not created elsewhere in this configuration.",
+						info.kaiakName, name, err))
+			}
+		}
 	}
-
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), req.ID)...)
-	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), parts[1])...)
 }
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -251,22 +1558,28 @@ func (r *dynamicResource) ImportState(ctx context.Context, req resource.ImportSt
 
 // extractAttrs reads all non-readonly kaiak attributes from a terraform
 // plan (or config). Block attributes are read by fetching the parent
-// object first, then extracting individual fields.
-func (r *dynamicResource) extractAttrs(ctx context.Context, src attrGetter, diags *diag.Diagnostics) schema.State {
+// object first, then extracting individual fields. Write-only attributes,
+// which are always null in the plan, are read from config, or skipped when
+// config is nil.
+func (r *dynamicResource) extractAttrs(ctx context.Context, src, config attrGetter, diags *diag.Diagnostics) schema.State {
 	state := make(schema.State)
 
 	// Top-level attributes
 	for _, info := range r.getInfos() {
-		if info.attr.ReadOnly || info.tfBlock != "" {
+		if info.attr.ReadOnly || info.alias || info.tfBlock != "" {
 			continue
 		}
-		extractSingleAttr(ctx, src, path.Root(info.tfField), info, state, diags)
+		if !info.attr.WriteOnly {
+			extractSingleAttr(ctx, src, path.Root(info.tfField), info, state, diags)
+		} else if config != nil {
+			extractSingleAttr(ctx, config, path.Root(info.tfField), info, state, diags)
+		}
 	}
 
 	// Block attributes — group by block name
 	blockGroups := map[string][]attrInfo{}
 	for _, info := range r.getInfos() {
-		if info.attr.ReadOnly || info.tfBlock == "" {
+		if info.attr.ReadOnly || info.alias || info.tfBlock == "" {
 			continue
 		}
 		blockGroups[info.tfBlock] = append(blockGroups[info.tfBlock], info)
@@ -274,7 +1587,7 @@ func (r *dynamicResource) extractAttrs(ctx context.Context, src attrGetter, diag
 
 	for blockName, infos := range blockGroups {
 		var block types.Object
-		diags.Append(src.GetAttribute(ctx, path.Root(blockName), &block)...)
+		diags.Append(src.GetAttribute(ctx, blockPath(blockName), &block)...)
 		if block.IsNull() || block.IsUnknown() {
 			continue
 		}
@@ -284,29 +1597,128 @@ func (r *dynamicResource) extractAttrs(ctx context.Context, src attrGetter, diag
 			if !ok {
 				continue
 			}
-			extractBlockAttr(info, v, state)
+			extractBlockAttr(ctx, info, v, state, diags)
 		}
 	}
 
 	return state
 }
 
+// createdDespite returns true if a create request failed in a way which may
+// hide a success, such as a timeout, a server error, or a conflict from a
+// repeated request, but the instance now exists. The instance either has a
+// newly generated label, or a configured one which was checked not to exist
+// before the request, so it was created by this request.
+func (r *dynamicResource) createdDespite(ctx context.Context, fullName string, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if status := httpStatus(err); status != 0 && status != http.StatusConflict && status < http.StatusInternalServerError {
+		return false
+	}
+	if _, getErr := r.client.GetResourceInstance(ctx, fullName); getErr != nil {
+		return false
+	}
+	logWarn(ctx, "Create request failed, but the instance exists. Treating it as created.", map[string]interface{}{
+		"instance": fullName,
+		"error":    err.Error(),
+	})
+	return true
+}
+
+// checkBodySize adds an error if the attributes would be sent in a request
+// body larger than max_body_size, before any request is made.
+func (r *dynamicResource) checkBodySize(fullName string, attrs schema.State, diags *diag.Diagnostics) {
+	if r.maxBody <= 0 {
+		return
+	}
+	data, err := json.Marshal(attrs)
+	if err != nil {
+		diags.AddError("Failed to encode attributes", err.Error())
+		return
+	}
+	if size := int64(len(data)); size > r.maxBody {
+		diags.AddError("Request body too large",
+			fmt.Sprintf("The attributes of %s encode to %d bytes, which exceeds the provider max_body_size of %d bytes. "+
+				"Reduce the size of the largest attributes, or raise max_body_size.", fullName, size, r.maxBody))
+	}
+}
+
+// mergeBlockAttrs adds the current server value of each writable member of
+// a block set in the plan which is not itself set, so that updating one
+// member of a block does not clear the others (a read-modify-write).
+func (r *dynamicResource) mergeBlockAttrs(ctx context.Context, fullName string, plan attrGetter, attrs schema.State, diags *diag.Diagnostics) {
+	result, err := r.client.GetResourceInstance(ctx, fullName)
+	if err != nil {
+		addClientError(ctx, diags, "Failed to read resource instance", err)
+		return
+	}
+	state := r.instanceState(result.Instance.State)
+
+	present := map[string]bool{}
+	for _, info := range r.getInfos() {
+		if info.attr.ReadOnly || info.alias || info.tfBlock == "" {
+			continue
+		}
+		if _, ok := present[info.tfBlock]; !ok {
+			var block types.Object
+			diags.Append(plan.GetAttribute(ctx, blockPath(info.tfBlock), &block)...)
+			present[info.tfBlock] = !block.IsNull() && !block.IsUnknown()
+		}
+		if _, ok := attrs[info.kaiakName]; ok || !present[info.tfBlock] {
+			continue
+		}
+		if v, ok := state[info.kaiakName]; ok && v != nil {
+			attrs[info.kaiakName] = v
+		}
+	}
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // PRIVATE — kaiak State → terraform state
 
+// getInstance reads an instance from the server, as part of a batch when
+// batch reads are enabled.
+func (r *dynamicResource) getInstance(ctx context.Context, fullName string) (*getResourceInstanceResponse, error) {
+	if r.reads != nil {
+		return r.reads.get(ctx, fullName)
+	}
+	return getResourceInstance(ctx, r.client, fullName)
+}
+
 // writeState fetches the instance from the server and populates
-// the terraform state with the id and all resource attributes.
+// the terraform state with the id and all resource attributes, and the
+// private state with its version.
 // For writable attributes not present in the server state, the value
 // from plannedAttrs (the Go values extracted from the plan) is preserved
-// so Terraform's consistency check does not fail.
-func (r *dynamicResource) writeState(ctx context.Context, fullName string, tfState *tfsdk.State, diags *diag.Diagnostics, plannedAttrs schema.State) {
-	result, err := r.client.GetResourceInstance(ctx, fullName)
+// so Terraform's consistency check does not fail. Any warnings the server
+// returns with the instance are added as warning diagnostics.
+func (r *dynamicResource) writeState(ctx context.Context, fullName string, tfState *tfsdk.State, private privateSetter, diags *diag.Diagnostics, plannedAttrs schema.State) {
+	result, err := r.getInstance(ctx, fullName)
 	if err != nil {
-		diags.AddError("Failed to read resource instance", err.Error())
+		addClientError(ctx, diags, "Failed to read resource instance", err)
 		return
 	}
 
-	kaiakState := result.Instance.State
+	// The version which a following update or destroy expects to modify
+	var version []byte
+	if result.Version != "" {
+		version, _ = json.Marshal(result.Version)
+	}
+	diags.Append(private.SetKey(ctx, versionKey, version)...)
+
+	// The schema the instance now matches, to detect a later schema change
+	if data, err := json.Marshal(r.meta.hash()); err == nil {
+		diags.Append(private.SetKey(ctx, appliedSchemaKey, data)...)
+	}
+
+	// Advisories from the server, such as use of a deprecated feature
+	for _, warning := range result.Warnings {
+		diags.AddWarning("Server warning for "+fullName, warning)
+	}
+
+	kaiakState := r.instanceState(result.Instance.State)
+	ctx = withTimeFormat(ctx, r.times)
 
 	// Fixed attributes
 	diags.Append(tfState.SetAttribute(ctx, path.Root("id"), types.StringValue(fullName))...)
@@ -329,16 +1741,52 @@ func (r *dynamicResource) writeState(ctx context.Context, fullName string, tfSta
 		}
 	}
 
+	// Write-only values are never stored in state, even if the server
+	// returns them
+	for _, info := range r.getInfos() {
+		if info.attr.WriteOnly {
+			delete(merged, info.kaiakName)
+		}
+	}
+
+	// Transformed values are kept in state as set in configuration
+	r.untransformState(ctx, merged)
+
+	// Order-insensitive computed lists are sorted, so refreshes are stable
+	for _, info := range r.getInfos() {
+		if items, ok := merged[info.kaiakName].([]any); ok && r.unordered(info) {
+			merged[info.kaiakName] = sortedElements(items)
+		}
+	}
+
 	// Top-level attributes
 	for _, info := range r.getInfos() {
 		if info.tfBlock != "" {
 			continue
 		}
 		v := merged[info.kaiakName]
-		diags.Append(tfState.SetAttribute(ctx, path.Root(info.tfField), kaiakValueToTF(ctx, v, info.attr.Type))...)
+		checkValueType(info, v, diags)
+		diags.Append(tfState.SetAttribute(ctx, path.Root(info.tfField), kaiakValueToTF(ctx, v, info.attr.Type, info.attr.Sensitive))...)
+	}
+
+	// The computed url attribute
+	if template := r.urlTemplate(ctx); template != "" {
+		diags.Append(tfState.SetAttribute(ctx, path.Root(urlAttribute), composeURL(template, merged))...)
+	}
+	if r.hasStatus() {
+		diags.Append(tfState.SetAttribute(ctx, path.Root(statusAttribute), r.instanceStatus(result, kaiakState))...)
+	}
+	if r.hasUUID() {
+		diags.Append(tfState.SetAttribute(ctx, path.Root(uuidAttribute), instanceUUID(result))...)
+	}
+	if r.hasLabel() {
+		if _, label, err := parseInstanceName(fullName); err == nil {
+			diags.Append(tfState.SetAttribute(ctx, path.Root(labelAttribute), types.StringValue(label))...)
+		}
 	}
 
-	// Block attributes — set each block as a typed object
+	// Block attributes — set each block as a typed object, each nested
+	// block before the block enclosing it
 	blockGroups := map[string][]attrInfo{}
 	for _, info := range r.getInfos() {
 		if info.tfBlock == "" {
@@ -347,7 +1795,11 @@ func (r *dynamicResource) writeState(ctx context.Context, fullName string, tfSta
 		blockGroups[info.tfBlock] = append(blockGroups[info.tfBlock], info)
 	}
 
-	for blockName, infos := range blockGroups {
+	extras := r.blockExtras(result.Instance.State)
+	names := blockNames(r.getInfos())
+	nested := map[string]map[string]types.Object{} // block name → nested block values
+	for i := len(names) - 1; i >= 0; i-- {
+		blockName, infos := names[i], blockGroups[names[i]]
 		attrTypes := make(map[string]attr.Type, len(infos))
 		attrValues := make(map[string]attr.Value, len(infos))
 		hasValue := false
@@ -356,20 +1808,476 @@ func (r *dynamicResource) writeState(ctx context.Context, fullName string, tfSta
 			attrTypes[info.tfField] = kaiakTypeToAttrType(info.attr.Type)
 			if v, ok := merged[info.kaiakName]; ok && v != nil {
 				hasValue = true
-				attrValues[info.tfField] = kaiakValueToTF(ctx, v, info.attr.Type)
+				checkValueType(info, v, diags)
+				attrValues[info.tfField] = kaiakValueToTF(ctx, v, info.attr.Type, info.attr.Sensitive)
 			} else {
 				attrValues[info.tfField] = kaiakNullValue(info.attr.Type)
 			}
 		}
+		for name, obj := range nested[blockName] {
+			attrTypes[name] = obj.Type(ctx)
+			attrValues[name] = obj
+			hasValue = hasValue || !obj.IsNull()
+		}
+
+		// Block members from the server which are missing from the schema
+		if _, exists := attrTypes[blockExtraField]; r.meta.BlockExtras && !exists {
+			attrTypes[blockExtraField] = types.MapType{ElemType: types.StringType}
+			attrValues[blockExtraField] = types.MapNull(types.StringType)
+			if members, ok := extras[blockName]; ok {
+				hasValue = true
+				attrValues[blockExtraField] = types.MapValueMust(types.StringType, members)
+			}
+		}
 
+		obj := types.ObjectNull(attrTypes)
 		if hasValue {
-			obj, d := types.ObjectValue(attrTypes, attrValues)
+			var d diag.Diagnostics
+			obj, d = types.ObjectValue(attrTypes, attrValues)
 			diags.Append(d...)
+		}
+		if parent, name := parentBlock(blockName); parent != "" {
+			if nested[parent] == nil {
+				nested[parent] = map[string]types.Object{}
+			}
+			nested[parent][name] = obj
+		} else {
 			diags.Append(tfState.SetAttribute(ctx, path.Root(blockName), obj)...)
+		}
+	}
+}
+
+// blockExtras returns the members of each block in the instance state from
+// the server which are not in the schema, keyed by block name and then field
+// name, with values other than strings encoded as JSON. Keys matched to an
+// attribute by state_key_case are not included.
+func (r *dynamicResource) blockExtras(state schema.State) map[string]map[string]attr.Value {
+	known := map[string]bool{}
+	blocks := map[string]bool{}
+	for _, info := range r.getInfos() {
+		known[info.kaiakName] = true
+		known[info.attr.Name] = true
+		if r.keyCase != "" && r.keyCase != keyCaseAsIs {
+			known[convertCase(info.kaiakName, info.attr.separator(), r.keyCase)] = true
+		}
+		if info.tfBlock != "" {
+			blocks[info.tfBlock] = true
+		}
+	}
+	extras := map[string]map[string]attr.Value{}
+	for key, v := range state {
+		info := newAttrInfo(attributeMeta{Attribute: schema.Attribute{Name: key}, Separator: r.meta.Separator})
+		if known[key] || !blocks[info.tfBlock] || v == nil {
+			continue
+		}
+		value, ok := v.(string)
+		if !ok {
+			data, err := json.Marshal(v)
+			if err != nil {
+				continue
+			}
+			value = string(data)
+		}
+		if extras[info.tfBlock] == nil {
+			extras[info.tfBlock] = map[string]attr.Value{}
+		}
+		extras[info.tfBlock][info.tfField] = types.StringValue(value)
+	}
+	return extras
+}
+
+// checkValueType warns when the server returns a value which does not match
+// the declared type of an attribute, which kaiakValueToTF converts on a
+// best-effort basis rather than failing to write state.
+func checkValueType(info attrInfo, v any, diags *diag.Diagnostics) {
+	if kaiakValueMatches(v, info.attr.Type) {
+		return
+	}
+	diags.AddAttributeWarning(info.path(), "Attribute type mismatch",
+		fmt.Sprintf("The server returned a value for %q which is not of its declared type %q, usually because "+
+			"the type of the attribute changed in a server upgrade. The value was converted where possible, "+
+			"and otherwise set to null. Check the attribute in state, and replace the instance with "+
+			"\"terraform apply -replace\" or remove it from state and re-import it.", info.kaiakName, info.attr.Type))
+}
+
+// instanceState returns the instance state from the server keyed by kaiak
+// attribute name. When the server's state keys are cased differently from
+// its attribute names, a key which does not match an attribute name is
+// matched by converting the name to the configured casing, so values are
+// not silently null.
+func (r *dynamicResource) instanceState(state schema.State) schema.State {
+	if r.keyCase == "" || r.keyCase == keyCaseAsIs {
+		return state
+	}
+	keyed := make(schema.State, len(state))
+	for k, v := range state {
+		keyed[k] = v
+	}
+	for _, info := range r.getInfos() {
+		if _, ok := keyed[info.kaiakName]; ok {
+			continue
+		}
+		if v, ok := state[convertCase(info.kaiakName, info.attr.separator(), r.keyCase)]; ok {
+			keyed[info.kaiakName] = v
+		}
+	}
+	return keyed
+}
+
+// convertCase converts each part of an attribute name, split by the
+// attribute separator, to snake_case or camelCase. A run of capitals is kept
+// together as one word in snake_case, so "tlsCertURL" becomes "tls_cert_url".
+func convertCase(name, sep, keyCase string) string {
+	parts := strings.Split(name, sep)
+	for i, part := range parts {
+		runes := []rune(part)
+		var b strings.Builder
+		upper := false
+		for j, c := range runes {
+			switch {
+			case keyCase == keyCaseSnake && unicode.IsUpper(c):
+				prevLower := j > 0 && !unicode.IsUpper(runes[j-1]) && runes[j-1] != '_'
+				nextLower := j > 0 && j+1 < len(runes) && unicode.IsLower(runes[j+1]) && unicode.IsUpper(runes[j-1])
+				if prevLower || nextLower {
+					b.WriteByte('_')
+				}
+				b.WriteRune(unicode.ToLower(c))
+			case keyCase == keyCaseCamel && (c == '_' || c == '-'):
+				upper = b.Len() > 0
+			case upper:
+				b.WriteRune(unicode.ToUpper(c))
+				upper = false
+			default:
+				b.WriteRune(c)
+			}
+		}
+		parts[i] = b.String()
+	}
+	return strings.Join(parts, sep)
+}
+
+// preserveState copies every top-level attribute or block which is not
+// named in refresh from the prior state, undoing the refresh of attributes
+// which legitimately fluctuate on the server and should not be tracked.
+func (r *dynamicResource) preserveState(ctx context.Context, prior tfsdk.State, tfState *tfsdk.State, refresh []string, diags *diag.Diagnostics) {
+	seen := map[string]bool{}
+	for _, info := range r.getInfos() {
+		name := info.path().Steps()[0].String()
+		if seen[name] || slices.Contains(refresh, name) {
+			continue
+		}
+		seen[name] = true
+
+		var v attr.Value
+		diags.Append(prior.GetAttribute(ctx, path.Root(name), &v)...)
+		diags.Append(tfState.SetAttribute(ctx, path.Root(name), v)...)
+	}
+	if r.hasStatus() && !slices.Contains(refresh, statusAttribute) {
+		var v types.String
+		diags.Append(prior.GetAttribute(ctx, path.Root(statusAttribute), &v)...)
+		diags.Append(tfState.SetAttribute(ctx, path.Root(statusAttribute), v)...)
+	}
+	if r.hasUUID() && !slices.Contains(refresh, uuidAttribute) {
+		var v types.String
+		diags.Append(prior.GetAttribute(ctx, path.Root(uuidAttribute), &v)...)
+		diags.Append(tfState.SetAttribute(ctx, path.Root(uuidAttribute), v)...)
+	}
+}
+
+// canonicalLists keeps each list attribute named in normalize_lists as it
+// is in ref (the plan, or the prior state on refresh) when the server holds
+// the same set of elements, so a server which sorts or deduplicates the list
+// does not cause a perpetual diff.
+func (r *dynamicResource) canonicalLists(ctx context.Context, ref attrGetter, tfState *tfsdk.State, diags *diag.Diagnostics) {
+	for _, info := range r.getInfos() {
+		if !strings.HasPrefix(info.attr.Type, "[]") || !slices.Contains(r.lists, info.kaiakName) {
+			continue
+		}
+		var want, got attr.Value
+		diags.Append(ref.GetAttribute(ctx, info.path(), &want)...)
+		diags.Append(tfState.GetAttribute(ctx, info.path(), &got)...)
+		wantList, ok1 := want.(types.List)
+		gotList, ok2 := got.(types.List)
+		if !ok1 || !ok2 || wantList.IsNull() || wantList.IsUnknown() || gotList.IsNull() {
+			continue
+		}
+		if sameElements(wantList.Elements(), gotList.Elements()) {
+			diags.Append(tfState.SetAttribute(ctx, info.path(), wantList)...)
+		}
+	}
+}
+
+// emptyCollections keeps each list or map attribute which is empty in ref
+// (the plan, or the prior state on refresh) empty rather than null, when
+// the server reports it as null after it was cleared by setting it to an
+// empty collection.
+func (r *dynamicResource) emptyCollections(ctx context.Context, ref attrGetter, tfState *tfsdk.State, diags *diag.Diagnostics) {
+	for _, info := range r.getInfos() {
+		if info.attr.ReadOnly || info.alias || !(strings.HasPrefix(info.attr.Type, "[]") || strings.HasPrefix(info.attr.Type, "map[")) {
+			continue
+		}
+		if info.tfBlock != "" {
+			var block types.Object
+			diags.Append(tfState.GetAttribute(ctx, blockPath(info.tfBlock), &block)...)
+			if block.IsNull() || block.IsUnknown() {
+				continue
+			}
+		}
+		var want, got attr.Value
+		diags.Append(ref.GetAttribute(ctx, info.path(), &want)...)
+		diags.Append(tfState.GetAttribute(ctx, info.path(), &got)...)
+		if got == nil || !got.IsNull() || want == nil || want.IsNull() || want.IsUnknown() {
+			continue
+		}
+		switch v := want.(type) {
+		case types.List:
+			if len(v.Elements()) == 0 {
+				diags.Append(tfState.SetAttribute(ctx, info.path(), v)...)
+			}
+		case types.Map:
+			if len(v.Elements()) == 0 {
+				diags.Append(tfState.SetAttribute(ctx, info.path(), v)...)
+			}
+		}
+	}
+}
+
+// presentBlocks sets each block which is present in ref (the plan, or the
+// prior state on refresh) but null in state, because the server reported no
+// value or default for any of its members, to an object with null members,
+// so that a block configured without members (e.g. "tls = {}") is not
+// reported as removed by the apply or refresh. A nested block is set after
+// the block enclosing it.
+func (r *dynamicResource) presentBlocks(ctx context.Context, ref attrGetter, tfState *tfsdk.State, diags *diag.Diagnostics) {
+	for _, block := range blockNames(r.getInfos()) {
+		var want, got types.Object
+		diags.Append(ref.GetAttribute(ctx, blockPath(block), &want)...)
+		diags.Append(tfState.GetAttribute(ctx, blockPath(block), &got)...)
+		if want.IsNull() || want.IsUnknown() || !got.IsNull() {
+			continue
+		}
+		values := make(map[string]attr.Value, len(got.AttributeTypes(ctx)))
+		for name, t := range got.AttributeTypes(ctx) {
+			v, err := t.ValueFromTerraform(ctx, tftypes.NewValue(t.TerraformType(ctx), nil))
+			if err != nil {
+				diags.AddError("Failed to set block", err.Error())
+				return
+			}
+			values[name] = v
+		}
+		obj, d := types.ObjectValue(got.AttributeTypes(ctx), values)
+		diags.Append(d...)
+		diags.Append(tfState.SetAttribute(ctx, blockPath(block), obj)...)
+	}
+}
+
+// emptyStrings keeps each string attribute for which an empty string is
+// null as it is in ref (the plan, or the prior state on refresh) when the
+// server reports it as empty or null, and ref is empty or null too.
+func (r *dynamicResource) emptyStrings(ctx context.Context, ref attrGetter, tfState *tfsdk.State, diags *diag.Diagnostics) {
+	for _, info := range r.getInfos() {
+		if !r.isEmptyNull(info) {
+			continue
+		}
+		if info.tfBlock != "" {
+			var block types.Object
+			diags.Append(tfState.GetAttribute(ctx, blockPath(info.tfBlock), &block)...)
+			if block.IsNull() || block.IsUnknown() {
+				continue
+			}
+		}
+		var want, got attr.Value
+		diags.Append(ref.GetAttribute(ctx, info.path(), &want)...)
+		diags.Append(tfState.GetAttribute(ctx, info.path(), &got)...)
+		if want == nil || got == nil || want.Equal(got) {
+			continue
+		}
+		if isEmptyString(ctx, want) && isEmptyString(ctx, got) {
+			diags.Append(tfState.SetAttribute(ctx, info.path(), want)...)
+		}
+	}
+}
+
+// isEmptyNull returns true if an empty string is the same as no value for a
+// writable string attribute, as declared by the server metadata or the
+// empty_as_null provider setting.
+func (r *dynamicResource) isEmptyNull(info attrInfo) bool {
+	if info.attr.ReadOnly || info.alias || info.attr.Type != "string" {
+		return false
+	}
+	return info.attr.EmptyAsNull || slices.Contains(r.empties, info.kaiakName)
+}
+
+// emptyAsNull replaces each empty string extracted from the plan, of an
+// attribute for which an empty string is null, with null, or removes it
+// from attrs when omit is set (on create, where null is the same as unset).
+func (r *dynamicResource) emptyAsNull(attrs schema.State, omit bool) {
+	for _, info := range r.getInfos() {
+		if v, ok := attrs[info.kaiakName]; !ok || v != "" || !r.isEmptyNull(info) {
+			continue
+		} else if omit {
+			delete(attrs, info.kaiakName)
 		} else {
-			diags.Append(tfState.SetAttribute(ctx, path.Root(blockName), types.ObjectNull(attrTypes))...)
+			attrs[info.kaiakName] = nil
+		}
+	}
+}
+
+// isEmptyString returns true if a string value is null or empty.
+func isEmptyString(ctx context.Context, v attr.Value) bool {
+	sv, ok := toStringValue(ctx, v)
+	return ok && !sv.IsUnknown() && (sv.IsNull() || sv.ValueString() == "")
+}
+
+// isEmptyCollection returns true if a value extracted from the plan is an
+// empty list or map.
+func isEmptyCollection(v any) bool {
+	if items, ok := kaiakItems(v); ok {
+		return len(items) == 0
+	}
+	if items, ok := v.(map[string]any); ok {
+		return len(items) == 0
+	}
+	return false
+}
+
+// unordered returns true if a computed list attribute is order-insensitive,
+// as declared by the server metadata or the normalize_lists provider setting,
+// in which case its elements are sorted in state.
+func (r *dynamicResource) unordered(info attrInfo) bool {
+	if !info.attr.ReadOnly || !strings.HasPrefix(info.attr.Type, "[]") {
+		return false
+	}
+	return info.attr.Unordered || slices.Contains(r.lists, info.kaiakName)
+}
+
+// sortedElements returns a sorted copy of list elements decoded from JSON.
+// Numbers and strings sort by value, and other elements by their JSON
+// encoding, so the order is deterministic whatever order the server uses.
+func sortedElements(items []any) []any {
+	sorted := slices.Clone(items)
+	slices.SortStableFunc(sorted, func(a, b any) int {
+		switch a := a.(type) {
+		case float64:
+			if b, ok := b.(float64); ok {
+				return cmp.Compare(a, b)
+			}
+		case string:
+			if b, ok := b.(string); ok {
+				return strings.Compare(a, b)
+			}
+		}
+		return strings.Compare(jsonString(a), jsonString(b))
+	})
+	return sorted
+}
+
+// sameElements returns true if a and b contain the same elements, ignoring
+// order and duplicates.
+func sameElements(a, b []attr.Value) bool {
+	contains := func(values []attr.Value, v attr.Value) bool {
+		return slices.ContainsFunc(values, v.Equal)
+	}
+	for _, v := range a {
+		if !contains(b, v) {
+			return false
+		}
+	}
+	for _, v := range b {
+		if !contains(a, v) {
+			return false
+		}
+	}
+	return true
+}
+
+// copySettings copies the provider-side settings, which are never sent to
+// the server, from the plan into the new state.
+func copySettings(ctx context.Context, plan tfsdk.Plan, tfState *tfsdk.State, diags *diag.Diagnostics) {
+	for _, name := range []string{"refresh_attributes", "merge_blocks"} {
+		var v attr.Value
+		diags.Append(plan.GetAttribute(ctx, path.Root(name), &v)...)
+		diags.Append(tfState.SetAttribute(ctx, path.Root(name), v)...)
+	}
+}
+
+// setLastApplied sets last_applied to the current time. It is called only
+// after a successful create or update, so a refresh keeps the prior value.
+func setLastApplied(ctx context.Context, tfState *tfsdk.State, diags *diag.Diagnostics) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	diags.Append(tfState.SetAttribute(ctx, path.Root("last_applied"), types.StringValue(now))...)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE — post-apply consistency verification
+
+// verifyState re-reads the instance from the server and compares every
+// attribute that was sent against the value the server now reports. Any
+// divergence is reported in a single diagnostic naming each attribute, in
+// place of the framework's opaque "inconsistent result after apply" error.
+// Values of sensitive attributes are never included in the report.
+func (r *dynamicResource) verifyState(ctx context.Context, fullName string, sent schema.State, diags *diag.Diagnostics) {
+	result, err := r.client.GetResourceInstance(ctx, fullName)
+	if err != nil {
+		addClientError(ctx, diags, "Failed to verify resource instance", err)
+		return
+	}
+	state := r.instanceState(result.Instance.State)
+
+	var diverged []string
+	for _, info := range r.getInfos() {
+		// A cleared attribute may be reset to a default by the server, and
+		// a write-only attribute may not be returned at all
+		want, ok := sent[info.kaiakName]
+		if !ok || want == nil || info.alias || info.attr.WriteOnly {
+			continue
+		}
+		got, ok := state[info.kaiakName]
+		switch {
+		case got == nil && isEmptyCollection(want):
+			// A collection emptied to clear it may be reported as null, or not at all
+		case !ok:
+			diverged = append(diverged, fmt.Sprintf("  %s: not returned by the server", info.kaiakName))
+		case !jsonEqual(want, got):
+			if info.attr.Sensitive {
+				diverged = append(diverged, fmt.Sprintf("  %s: planned and server values differ (sensitive)", info.kaiakName))
+			} else {
+				diverged = append(diverged, fmt.Sprintf("  %s: planned %s, server %s", info.kaiakName, jsonString(want), jsonString(got)))
+			}
 		}
 	}
+	if len(diverged) == 0 {
+		return
+	}
+
+	sort.Strings(diverged)
+	diags.AddError("Resource instance is inconsistent after apply",
+		fmt.Sprintf("The server state of %s does not match the applied configuration:\n%s\n\n"+
+			"The server may be normalizing or ignoring these values. Adjust the configuration "+
+			"to match the server's canonical form.", fullName, strings.Join(diverged, "\n")))
+}
+
+// jsonEqual compares two values by their JSON representation, so that a
+// typed Go value sent to the server (e.g. int64) compares equal to the
+// decoded JSON value returned from it (e.g. float64).
+func jsonEqual(a, b any) bool {
+	var na, nb any
+	if data, err := json.Marshal(a); err != nil || json.Unmarshal(data, &na) != nil {
+		return false
+	}
+	if data, err := json.Marshal(b); err != nil || json.Unmarshal(data, &nb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(na, nb)
+}
+
+// jsonString returns the JSON representation of a value for diagnostics.
+func jsonString(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
 }
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -401,28 +2309,39 @@ func extractSingleAttr(ctx context.Context, src attrGetter, p path.Path, info at
 		var v types.List
 		diags.Append(src.GetAttribute(ctx, p, &v)...)
 		if !v.IsNull() && !v.IsUnknown() {
-			state[info.kaiakName] = tfListToKaiak(v, info.attr.Type[2:])
+			if items, err := tfListToKaiak(v, info.attr.Type[2:]); err != nil {
+				addElemError(diags, p, info, err)
+			} else {
+				state[info.kaiakName] = items
+			}
 		}
 	case strings.HasPrefix(info.attr.Type, "map["):
 		var v types.Map
 		diags.Append(src.GetAttribute(ctx, p, &v)...)
 		if !v.IsNull() && !v.IsUnknown() {
 			if idx := strings.Index(info.attr.Type, "]"); idx >= 0 && idx+1 < len(info.attr.Type) {
-				state[info.kaiakName] = tfMapToKaiak(v, info.attr.Type[idx+1:])
+				if items, err := tfMapToKaiak(v, info.attr.Type[idx+1:]); err != nil {
+					addElemError(diags, p, info, err)
+				} else {
+					state[info.kaiakName] = items
+				}
 			}
 		}
 	default:
-		var v types.String
+		// Read as a generic value, since string attributes may have a
+		// custom (normalized) type
+		var v attr.Value
 		diags.Append(src.GetAttribute(ctx, p, &v)...)
-		if !v.IsNull() && !v.IsUnknown() {
-			state[info.kaiakName] = v.ValueString()
+		if sv, ok := toStringValue(ctx, v); ok && !sv.IsNull() && !sv.IsUnknown() {
+			state[info.kaiakName] = sv.ValueString()
 		}
 	}
 }
 
 // extractBlockAttr reads a single attribute from a block object value and
 // stores the Go value into the kaiak state map.
-func extractBlockAttr(info attrInfo, v attr.Value, state schema.State) {
+func extractBlockAttr(ctx context.Context, info attrInfo, v attr.Value, state schema.State, diags *diag.Diagnostics) {
+	p := info.path()
 	switch {
 	case info.attr.Type == "bool":
 		if bv, ok := v.(types.Bool); ok && !bv.IsNull() && !bv.IsUnknown() {
@@ -438,60 +2357,126 @@ func extractBlockAttr(info attrInfo, v attr.Value, state schema.State) {
 		}
 	case strings.HasPrefix(info.attr.Type, "[]"):
 		if lv, ok := v.(types.List); ok && !lv.IsNull() && !lv.IsUnknown() {
-			state[info.kaiakName] = tfListToKaiak(lv, info.attr.Type[2:])
+			if items, err := tfListToKaiak(lv, info.attr.Type[2:]); err != nil {
+				addElemError(diags, p, info, err)
+			} else {
+				state[info.kaiakName] = items
+			}
 		}
 	case strings.HasPrefix(info.attr.Type, "map["):
 		if mv, ok := v.(types.Map); ok && !mv.IsNull() && !mv.IsUnknown() {
 			if idx := strings.Index(info.attr.Type, "]"); idx >= 0 && idx+1 < len(info.attr.Type) {
-				state[info.kaiakName] = tfMapToKaiak(mv, info.attr.Type[idx+1:])
+				if items, err := tfMapToKaiak(mv, info.attr.Type[idx+1:]); err != nil {
+					addElemError(diags, p, info, err)
+				} else {
+					state[info.kaiakName] = items
+				}
 			}
 		}
 	default:
-		if sv, ok := v.(types.String); ok && !sv.IsNull() && !sv.IsUnknown() {
+		if sv, ok := toStringValue(ctx, v); ok && !sv.IsNull() && !sv.IsUnknown() {
 			state[info.kaiakName] = sv.ValueString()
 		}
 	}
 }
 
+// addElemError reports a list or map element conversion error against the
+// attribute path, rather than sending a mis-typed value to the server.
+func addElemError(diags *diag.Diagnostics, p path.Path, info attrInfo, err error) {
+	diags.AddAttributeError(p, "Invalid attribute element",
+		fmt.Sprintf("Attribute %q (type %q): %s", info.kaiakName, info.attr.Type, err))
+}
+
 // tfListToKaiak converts a terraform ListValue to a Go slice for the kaiak API.
-func tfListToKaiak(list types.List, elemType string) []interface{} {
+// A list of scalars converts to a typed slice ([]string, []int64, []float64
+// or []bool), so that large lists are not boxed element by element, and any
+// other list to a []interface{}. An error is returned if any element does
+// not match the declared element type.
+func tfListToKaiak(list types.List, elemType string) (any, error) {
 	elems := list.Elements()
-	result := make([]interface{}, 0, len(elems))
-	for _, e := range elems {
-		result = append(result, tfElemToGo(e, elemType))
+	switch {
+	case elemType == "bool":
+		return typedElems(elems, elemType, types.Bool.ValueBool)
+	case elemType == "int" || elemType == "uint":
+		return typedElems(elems, elemType, types.Int64.ValueInt64)
+	case elemType == "float":
+		return typedElems(elems, elemType, types.Float64.ValueFloat64)
+	case strings.HasPrefix(elemType, "[]") || strings.HasPrefix(elemType, "map["):
+		result := make([]interface{}, len(elems))
+		for i, e := range elems {
+			v, err := tfElemToGo(e, elemType)
+			if err != nil {
+				return nil, fmt.Errorf("element %d: %w", i, err)
+			}
+			result[i] = v
+		}
+		return result, nil
+	default:
+		return typedElems(elems, elemType, types.String.ValueString)
+	}
+}
+
+// typedElems converts list elements of terraform value type V to a slice
+// of T, returning an error for an element of any other type.
+func typedElems[V attr.Value, T any](elems []attr.Value, t string, value func(V) T) ([]T, error) {
+	result := make([]T, len(elems))
+	for i, e := range elems {
+		v, ok := e.(V)
+		if !ok {
+			return nil, fmt.Errorf("element %d: value of type %s does not match declared type %q", i, e.Type(context.Background()), t)
+		}
+		result[i] = value(v)
 	}
-	return result
+	return result, nil
 }
 
 // tfMapToKaiak converts a terraform MapValue to a Go map for the kaiak API.
-func tfMapToKaiak(m types.Map, valType string) map[string]interface{} {
+// An error is returned if any value does not match the declared value type.
+func tfMapToKaiak(m types.Map, valType string) (map[string]interface{}, error) {
 	elems := m.Elements()
 	result := make(map[string]interface{}, len(elems))
 	for k, e := range elems {
-		result[k] = tfElemToGo(e, valType)
+		v, err := tfElemToGo(e, valType)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", k, err)
+		}
+		result[k] = v
 	}
-	return result
+	return result, nil
 }
 
 // tfElemToGo converts a terraform attr.Value to its Go equivalent for a
-// given kaiak type string.
-func tfElemToGo(v attr.Value, t string) interface{} {
-	switch t {
-	case "bool":
+// given kaiak type string, recursing into nested lists and maps (e.g.
+// "[]map[string]string"). An error is returned when the concrete value
+// type does not match the declared kaiak type.
+func tfElemToGo(v attr.Value, t string) (interface{}, error) {
+	switch {
+	case t == "bool":
 		if bv, ok := v.(types.Bool); ok {
-			return bv.ValueBool()
+			return bv.ValueBool(), nil
 		}
-	case "int", "uint":
+	case t == "int" || t == "uint":
 		if iv, ok := v.(types.Int64); ok {
-			return iv.ValueInt64()
+			return iv.ValueInt64(), nil
 		}
-	case "float":
+	case t == "float":
 		if fv, ok := v.(types.Float64); ok {
-			return fv.ValueFloat64()
+			return fv.ValueFloat64(), nil
+		}
+	case strings.HasPrefix(t, "[]"):
+		if lv, ok := v.(types.List); ok {
+			return tfListToKaiak(lv, t[2:])
+		}
+	case strings.HasPrefix(t, "map["):
+		if mv, ok := v.(types.Map); ok {
+			if idx := strings.Index(t, "]"); idx >= 0 && idx+1 < len(t) {
+				return tfMapToKaiak(mv, t[idx+1:])
+			}
+		}
+	default:
+		if sv, ok := v.(types.String); ok {
+			return sv.ValueString(), nil
 		}
 	}
-	if sv, ok := v.(types.String); ok {
-		return sv.ValueString()
-	}
-	return fmt.Sprintf("%v", v)
+	return nil, fmt.Errorf("value of type %s does not match declared type %q", v.Type(context.Background()), t)
 }
@@ -0,0 +1,815 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	// Packages
+	attr "github.com/hashicorp/terraform-plugin-framework/attr"
+	providerserver "github.com/hashicorp/terraform-plugin-framework/providerserver"
+	types "github.com/hashicorp/terraform-plugin-framework/types"
+	tfprotov6 "github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	tftypes "github.com/hashicorp/terraform-plugin-go/tftypes"
+	schema "github.com/mutablelogic/go-server/pkg/provider/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+// testServer is a server holding instances in memory, which lists the given
+// resource types and sets its defaults on each instance it creates.
+type testServer struct {
+	*httptest.Server
+	sync.Mutex
+	resources []resourceMeta
+	defaults  schema.State            // attribute name → value set on create
+	instances map[string]schema.State // instance name → state
+	requests  []string                // method, path and query of each instance request, and creates
+	lists     int                     // number of resource list requests
+	failPatch bool                    // whether to reject instance updates
+}
+
+// testProvider is a provider served over the plugin protocol, as Terraform
+// runs it, configured against a test server.
+type testProvider struct {
+	t       *testing.T
+	server  tfprotov6.ProviderServer
+	schemas map[string]*tfprotov6.Schema
+}
+
+// newTestServer returns a test server listing the resource types, which is
+// closed when the test ends.
+func newTestServer(t *testing.T, resources ...resourceMeta) *testServer {
+	t.Helper()
+	s := &testServer{resources: resources, defaults: schema.State{}, instances: map[string]schema.State{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// serve handles the resource list, and creating, reading, updating,
+// renaming and destroying instances.
+func (s *testServer) serve(w http.ResponseWriter, req *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+	_, rest, _ := strings.Cut(req.URL.Path, "/resource")
+	rest = strings.TrimPrefix(rest, "/")
+	if rest != "" {
+		s.requests = append(s.requests, strings.TrimSuffix(req.Method+" "+rest+"?"+req.URL.RawQuery, "?"))
+	}
+
+	reply := func(v any) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}
+	instance := func(name string) any {
+		return schema.GetResourceInstanceResponse{Instance: schema.InstanceMeta{
+			Name:     name,
+			Resource: strings.SplitN(name, ".", 2)[0],
+			State:    s.instances[name],
+		}}
+	}
+	name, action, _ := strings.Cut(rest, "/")
+	switch {
+	case rest == "" && req.Method == http.MethodGet:
+		s.lists++
+		reply(listResourcesResponse{Provider: "test", Resources: s.resources})
+	case rest == "" && req.Method == http.MethodPost:
+		var body createResourceInstanceRequest
+		json.NewDecoder(req.Body).Decode(&body)
+		s.requests = append(s.requests, "POST "+body.Name)
+		if _, exists := s.instances[body.Name]; exists {
+			http.Error(w, "instance exists", http.StatusConflict)
+			return
+		}
+		state := maps.Clone(s.defaults)
+		maps.Copy(state, body.Attributes)
+		s.instances[body.Name] = state
+		w.WriteHeader(http.StatusCreated)
+		reply(instance(body.Name))
+	case s.instances[name] == nil:
+		http.Error(w, "instance not found", http.StatusNotFound)
+	case req.Method == http.MethodGet:
+		reply(instance(name))
+	case req.Method == http.MethodPatch && s.failPatch:
+		http.Error(w, "update rejected", http.StatusBadRequest)
+	case req.Method == http.MethodPatch:
+		var body schema.UpdateResourceInstanceRequest
+		json.NewDecoder(req.Body).Decode(&body)
+		if body.Apply {
+			for key, v := range body.Attributes {
+				if v == nil {
+					delete(s.instances[name], key)
+				} else {
+					s.instances[name][key] = v
+				}
+			}
+		}
+		reply(instance(name))
+	case req.Method == http.MethodPost && action == "rename":
+		var body renameResourceInstanceRequest
+		json.NewDecoder(req.Body).Decode(&body)
+		s.instances[body.Name] = s.instances[name]
+		delete(s.instances, name)
+		reply(instance(body.Name))
+	case req.Method == http.MethodDelete:
+		reply(schema.DestroyResourceInstanceResponse{Instances: []schema.InstanceMeta{{Name: name}}})
+		delete(s.instances, name)
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+	}
+}
+
+// state returns a copy of the state of an instance, or nil if it does not
+// exist.
+func (s *testServer) state(name string) schema.State {
+	s.Lock()
+	defer s.Unlock()
+	return maps.Clone(s.instances[name])
+}
+
+// newTestProvider returns a provider configured against the test server,
+// with the given provider block attributes and all others null.
+func newTestProvider(t *testing.T, srv *testServer, attrs map[string]tftypes.Value) *testProvider {
+	t.Helper()
+	p, diags := serveProvider(t, srv, attrs)
+	checkDiagnostics(t, "ConfigureProvider", diags)
+	return p
+}
+
+// serveProvider returns a provider against the test server, which has its
+// schemas read and then is configured with the given provider block
+// attributes, as Terraform does, and the diagnostics of configuring it.
+func serveProvider(t *testing.T, srv *testServer, attrs map[string]tftypes.Value) (*testProvider, []*tfprotov6.Diagnostic) {
+	t.Helper()
+	t.Setenv("KAIAK_ENDPOINT", srv.URL)
+	ctx := context.Background()
+	server, err := providerserver.NewProtocol6WithError(New("test")())()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := server.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &testProvider{t: t, server: server, schemas: resp.ResourceSchemas}
+	checkDiagnostics(t, "GetProviderSchema", resp.Diagnostics)
+
+	attrs = maps.Clone(attrs)
+	if attrs == nil {
+		attrs = map[string]tftypes.Value{}
+	}
+	if _, ok := attrs["endpoint"]; !ok {
+		attrs["endpoint"] = stringValue(srv.URL)
+	}
+	config := objectValue(t, resp.Provider.ValueType().(tftypes.Object), attrs)
+	configResp, err := server.ConfigureProvider(ctx, &tfprotov6.ConfigureProviderRequest{Config: dynamicValue(t, config)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, configResp.Diagnostics
+}
+
+// apply plans and applies the configuration of a resource, with the given
+// attributes and all others null, over the prior state, and returns the new
+// state. A zero prior state is that of a new instance. It fails the test if
+// the new state differs from a known value in the plan, which Terraform
+// reports as an inconsistent result after apply.
+func (p *testProvider) apply(typeName string, prior tftypes.Value, attrs map[string]tftypes.Value) tftypes.Value {
+	p.t.Helper()
+	if prior.Type() == nil {
+		prior = tftypes.NewValue(p.schemas[typeName].ValueType(), nil)
+	}
+	planned := p.plan(typeName, prior, attrs)
+	return p.applyPlanned(typeName, prior, planned, attrs)
+}
+
+// plan plans the configuration of a resource, with the given attributes and
+// all others null, over the prior state, and returns the planned state. A
+// zero prior state is that of a new instance.
+func (p *testProvider) plan(typeName string, prior tftypes.Value, attrs map[string]tftypes.Value) tftypes.Value {
+	p.t.Helper()
+	ctx := context.Background()
+	s := p.schemas[typeName]
+	if s == nil {
+		p.t.Fatalf("no schema for %q", typeName)
+	}
+	typ := s.ValueType().(tftypes.Object)
+	if prior.Type() == nil {
+		prior = tftypes.NewValue(typ, nil)
+	}
+	config := objectValue(p.t, typ, attrs)
+	resp, err := p.server.PlanResourceChange(ctx, &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         typeName,
+		PriorState:       dynamicValue(p.t, prior),
+		ProposedNewState: dynamicValue(p.t, proposedNew(s.Block.Attributes, prior, config)),
+		Config:           dynamicValue(p.t, config),
+	})
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	checkDiagnostics(p.t, "PlanResourceChange", resp.Diagnostics)
+	planned, err := resp.PlannedState.Unmarshal(typ)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	return planned
+}
+
+// applyPlanned applies a planned state, and returns the new state.
+func (p *testProvider) applyPlanned(typeName string, prior, planned tftypes.Value, attrs map[string]tftypes.Value) tftypes.Value {
+	p.t.Helper()
+	ctx := context.Background()
+	typ := p.schemas[typeName].ValueType().(tftypes.Object)
+	resp, err := p.server.ApplyResourceChange(ctx, &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:     typeName,
+		PriorState:   dynamicValue(p.t, prior),
+		PlannedState: dynamicValue(p.t, planned),
+		Config:       dynamicValue(p.t, objectValue(p.t, typ, attrs)),
+	})
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	checkDiagnostics(p.t, "ApplyResourceChange", resp.Diagnostics)
+	state, err := resp.NewState.Unmarshal(typ)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	checkConsistent(p.t, planned, state)
+	return state
+}
+
+// read refreshes the state of a resource, and returns the new state.
+func (p *testProvider) read(typeName string, current tftypes.Value) tftypes.Value {
+	p.t.Helper()
+	typ := p.schemas[typeName].ValueType().(tftypes.Object)
+	resp, err := p.server.ReadResource(context.Background(), &tfprotov6.ReadResourceRequest{
+		TypeName:     typeName,
+		CurrentState: dynamicValue(p.t, current),
+	})
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	checkDiagnostics(p.t, "ReadResource", resp.Diagnostics)
+	state, err := resp.NewState.Unmarshal(typ)
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	return state
+}
+
+// destroy destroys a resource, and fails the test if its state is not
+// removed.
+func (p *testProvider) destroy(typeName string, prior tftypes.Value) {
+	p.t.Helper()
+	typ := p.schemas[typeName].ValueType()
+	resp, err := p.server.ApplyResourceChange(context.Background(), &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:     typeName,
+		PriorState:   dynamicValue(p.t, prior),
+		PlannedState: dynamicValue(p.t, tftypes.NewValue(typ, nil)),
+		Config:       dynamicValue(p.t, tftypes.NewValue(typ, nil)),
+	})
+	if err != nil {
+		p.t.Fatal(err)
+	}
+	checkDiagnostics(p.t, "ApplyResourceChange", resp.Diagnostics)
+	if state, err := resp.NewState.Unmarshal(typ); err != nil {
+		p.t.Fatal(err)
+	} else if !state.IsNull() {
+		p.t.Errorf("state %s, want null after destroy", state)
+	}
+}
+
+// proposedNew returns the proposed new state which Terraform sends with a
+// plan: the configuration, with each computed attribute which is not set
+// taking its prior value, within nested attributes too.
+func proposedNew(attrs []*tfprotov6.SchemaAttribute, prior, config tftypes.Value) tftypes.Value {
+	if config.IsNull() || !config.IsKnown() {
+		return config
+	}
+	var priorValues, configValues map[string]tftypes.Value
+	if !prior.IsNull() {
+		prior.As(&priorValues)
+	}
+	config.As(&configValues)
+	values := maps.Clone(configValues)
+	for _, a := range attrs {
+		v, was := configValues[a.Name], priorValues[a.Name]
+		switch {
+		case v.IsNull() && a.Computed && !was.IsNull() && was.Type() != nil:
+			values[a.Name] = was
+		case a.NestedType != nil && a.NestedType.Nesting == tfprotov6.SchemaObjectNestingModeSingle:
+			if was.Type() == nil {
+				was = tftypes.NewValue(v.Type(), nil)
+			}
+			values[a.Name] = proposedNew(a.NestedType.Attributes, was, v)
+		}
+	}
+	return tftypes.NewValue(config.Type(), values)
+}
+
+// checkConsistent fails the test if a known value in the plan differs from
+// the new state, or a value planned to be present, with members which are
+// not yet known, is null.
+func checkConsistent(t *testing.T, planned, state tftypes.Value) {
+	t.Helper()
+	diffs, err := planned.Diff(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, diff := range diffs {
+		if diff.Value1 == nil || !diff.Value1.IsKnown() {
+			continue
+		}
+		if diff.Value1.IsFullyKnown() || (!diff.Value1.IsNull() && diff.Value2 != nil && diff.Value2.IsNull()) {
+			t.Errorf("inconsistent result after apply: %s planned %s, got %s", diff.Path, diff.Value1, diff.Value2)
+		}
+	}
+}
+
+// checkDiagnostics fails the test if there are any error diagnostics.
+func checkDiagnostics(t *testing.T, op string, diags []*tfprotov6.Diagnostic) {
+	t.Helper()
+	for _, d := range diags {
+		if d.Severity == tfprotov6.DiagnosticSeverityError {
+			t.Fatalf("%s: %s: %s", op, d.Summary, d.Detail)
+		}
+	}
+}
+
+// objectValue returns an object of the type with the given attributes, and
+// all others null.
+func objectValue(t *testing.T, typ tftypes.Object, attrs map[string]tftypes.Value) tftypes.Value {
+	t.Helper()
+	values := make(map[string]tftypes.Value, len(typ.AttributeTypes))
+	for name, attrType := range typ.AttributeTypes {
+		values[name] = tftypes.NewValue(attrType, nil)
+	}
+	for name, v := range attrs {
+		if _, ok := typ.AttributeTypes[name]; !ok {
+			t.Fatalf("no attribute %q", name)
+		}
+		values[name] = v
+	}
+	return tftypes.NewValue(typ, values)
+}
+
+// dynamicValue encodes a value for the plugin protocol.
+func dynamicValue(t *testing.T, v tftypes.Value) *tfprotov6.DynamicValue {
+	t.Helper()
+	dv, err := tfprotov6.NewDynamicValue(v.Type(), v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &dv
+}
+
+// attrValue returns the value at a path of attribute names in a state.
+func attrValue(t *testing.T, state tftypes.Value, names ...string) tftypes.Value {
+	t.Helper()
+	p := tftypes.NewAttributePath()
+	for _, name := range names {
+		p = p.WithAttributeName(name)
+	}
+	v, _, err := tftypes.WalkAttributePath(state, p)
+	if err != nil {
+		t.Fatalf("%s: %v", p, err)
+	}
+	return v.(tftypes.Value)
+}
+
+// boxedListToKaiak converts a list to a []interface{}, boxing each element,
+// as tfListToKaiak did for lists of scalars before it returned typed slices.
+func boxedListToKaiak(list types.List, elemType string) (any, error) {
+	elems := list.Elements()
+	result := make([]interface{}, len(elems))
+	for i, e := range elems {
+		v, err := tfElemToGo(e, elemType)
+		if err != nil {
+			return nil, err
+		}
+		result[i] = v
+	}
+	return result, nil
+}
+
+// attribute returns a resource attribute of the given type.
+func attribute(name, typ string) attributeMeta {
+	return attributeMeta{Attribute: schema.Attribute{Name: name, Type: typ}}
+}
+
+func TestNestedBlocks(t *testing.T) {
+	srv := newTestServer(t, resourceMeta{Name: "x", Attributes: []attributeMeta{
+		attribute("tls.mode", "string"),
+		attribute("tls.client.cert", "string"),
+		attribute("tls.client.port", "int"),
+	}})
+	srv.defaults["tls.client.port"] = 443
+	p := newTestProvider(t, srv, nil)
+
+	typ := p.schemas["kaiak_x"].ValueType().(tftypes.Object)
+	tlsType := typ.AttributeTypes["tls"].(tftypes.Object)
+	clientType := tlsType.AttributeTypes["client"].(tftypes.Object)
+	tls := tftypes.NewValue(tlsType, map[string]tftypes.Value{
+		"mode": stringValue("strict"),
+		"client": tftypes.NewValue(clientType, map[string]tftypes.Value{
+			"cert": stringValue("cert.pem"),
+			"port": tftypes.NewValue(tftypes.Number, nil),
+		}),
+	})
+
+	// The nested members are sent to the server, and its default read back
+	state := p.apply("kaiak_x", tftypes.Value{}, map[string]tftypes.Value{"tls": tls})
+	var id string
+	attrValue(t, state, "id").As(&id)
+	got := srv.state(id)
+	if got["tls.mode"] != "strict" || got["tls.client.cert"] != "cert.pem" {
+		t.Errorf("server state %v", got)
+	}
+	if v := attrValue(t, state, "tls", "client", "port"); !v.Equal(tftypes.NewValue(tftypes.Number, 443)) {
+		t.Errorf("tls.client.port %s, want 443", v)
+	}
+
+	// Changing a nested member updates it
+	tls = tftypes.NewValue(tlsType, map[string]tftypes.Value{
+		"mode": stringValue("strict"),
+		"client": tftypes.NewValue(clientType, map[string]tftypes.Value{
+			"cert": stringValue("other.pem"),
+			"port": tftypes.NewValue(tftypes.Number, nil),
+		}),
+	})
+	p.apply("kaiak_x", state, map[string]tftypes.Value{"tls": tls})
+	if got := srv.state(id); got["tls.client.cert"] != "other.pem" {
+		t.Errorf("server state %v", got)
+	}
+}
+
+func TestDeleteMode(t *testing.T) {
+	for mode, want := range map[string]string{
+		"":       "DELETE x.a",
+		"purge":  "DELETE x.a",
+		"retain": "DELETE x.a?mode=retain",
+	} {
+		t.Run(mode, func(t *testing.T) {
+			srv := newTestServer(t, resourceMeta{Name: "x", Attributes: []attributeMeta{attribute("value", "string")}})
+			attrs := map[string]tftypes.Value{}
+			if mode != "" {
+				attrs["delete_mode"] = stringValue(mode)
+			}
+			p := newTestProvider(t, srv, attrs)
+
+			state := p.apply("kaiak_x", tftypes.Value{}, map[string]tftypes.Value{"label": stringValue("a")})
+			p.destroy("kaiak_x", state)
+			if got := srv.requests[len(srv.requests)-1]; got != want {
+				t.Errorf("request %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestCreateBeforeDestroy(t *testing.T) {
+	srv := newTestServer(t, resourceMeta{Name: "x", Attributes: []attributeMeta{attribute("value", "string")}})
+	p := newTestProvider(t, srv, nil)
+	config := map[string]tftypes.Value{"label": stringValue("a"), "value": stringValue("v")}
+	old := p.apply("kaiak_x", tftypes.Value{}, config)
+
+	// Changing the label requires a replacement
+	config["label"] = stringValue("b")
+	resp, err := p.server.PlanResourceChange(context.Background(), &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         "kaiak_x",
+		PriorState:       dynamicValue(t, old),
+		ProposedNewState: dynamicValue(t, proposedNew(p.schemas["kaiak_x"].Block.Attributes, old, objectValue(t, old.Type().(tftypes.Object), config))),
+		Config:           dynamicValue(t, objectValue(t, old.Type().(tftypes.Object), config)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkDiagnostics(t, "PlanResourceChange", resp.Diagnostics)
+	if len(resp.RequiresReplace) != 1 || resp.RequiresReplace[0].String() != tftypes.NewAttributePath().WithAttributeName("label").String() {
+		t.Fatalf("requires replace %v, want label", resp.RequiresReplace)
+	}
+
+	// The new instance is created alongside the old one, after checking it
+	// does not exist, and the old one is then destroyed
+	n := len(srv.requests)
+	state := p.apply("kaiak_x", tftypes.Value{}, config)
+	if got := srv.requests[n : n+2]; got[0] != "GET x.b" || got[1] != "POST x.b" {
+		t.Errorf("requests %q, want the check and create of x.b", got)
+	}
+	if srv.state("x.a") == nil || srv.state("x.b") == nil {
+		t.Fatalf("instances %v, want x.a and x.b", srv.instances)
+	}
+	p.destroy("kaiak_x", old)
+	if srv.state("x.a") != nil || srv.state("x.b")["value"] != "v" {
+		t.Errorf("instances %v, want x.b", srv.instances)
+	}
+	if id := attrValue(t, state, "id"); !id.Equal(stringValue("x.b")) {
+		t.Errorf("id %s, want x.b", id)
+	}
+
+	// A generated label is new for each replacement, so is not checked
+	// for an existing instance
+	delete(config, "label")
+	first := p.apply("kaiak_x", tftypes.Value{}, config)
+	n = len(srv.requests)
+	second := p.apply("kaiak_x", tftypes.Value{}, config)
+	if attrValue(t, first, "id").Equal(attrValue(t, second, "id")) {
+		t.Error("replacement has the same id as the instance it replaces")
+	}
+	var id string
+	attrValue(t, second, "id").As(&id)
+	if got := srv.requests[n]; got != "POST "+id {
+		t.Errorf("first request %q, want the create of %s", got, id)
+	}
+}
+
+func TestRenameInPlace(t *testing.T) {
+	srv := newTestServer(t, resourceMeta{Name: "x", Attributes: []attributeMeta{attribute("value", "string")}})
+	p := newTestProvider(t, srv, map[string]tftypes.Value{"allow_rename_in_place": tftypes.NewValue(tftypes.Bool, true)})
+	config := map[string]tftypes.Value{"label": stringValue("a"), "value": stringValue("v")}
+	state := p.apply("kaiak_x", tftypes.Value{}, config)
+
+	// Changing the label renames the instance, and plans its new id
+	config["label"], config["value"] = stringValue("b"), stringValue("w")
+	planned := p.plan("kaiak_x", state, config)
+	if id := attrValue(t, planned, "id"); !id.Equal(stringValue("x.b")) {
+		t.Errorf("planned id %s, want x.b", id)
+	}
+	state = p.applyPlanned("kaiak_x", state, planned, config)
+	if srv.state("x.a") != nil || srv.state("x.b")["value"] != "w" {
+		t.Errorf("instances %v, want x.b with the new value", srv.instances)
+	}
+	if id := attrValue(t, state, "id"); !id.Equal(stringValue("x.b")) {
+		t.Errorf("id %s, want x.b", id)
+	}
+}
+
+func TestRenameThenFail(t *testing.T) {
+	srv := newTestServer(t, resourceMeta{Name: "x", Attributes: []attributeMeta{attribute("value", "string")}})
+	p := newTestProvider(t, srv, map[string]tftypes.Value{"allow_rename_in_place": tftypes.NewValue(tftypes.Bool, true)})
+	config := map[string]tftypes.Value{"label": stringValue("a"), "value": stringValue("v")}
+	state := p.apply("kaiak_x", tftypes.Value{}, config)
+
+	// The rename succeeds and the update of the attributes then fails
+	config["label"], config["value"] = stringValue("b"), stringValue("w")
+	planned := p.plan("kaiak_x", state, config)
+	srv.failPatch = true
+	typ := p.schemas["kaiak_x"].ValueType().(tftypes.Object)
+	resp, err := p.server.ApplyResourceChange(context.Background(), &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:     "kaiak_x",
+		PriorState:   dynamicValue(t, state),
+		PlannedState: dynamicValue(t, planned),
+		Config:       dynamicValue(t, objectValue(t, typ, config)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	} else if len(resp.Diagnostics) == 0 {
+		t.Fatal("update succeeded, want an error")
+	}
+	if srv.state("x.b") == nil {
+		t.Fatal("instance x.b not renamed")
+	}
+
+	// State records the new name, so the instance can still be read
+	state, err = resp.NewState.Unmarshal(typ)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := attrValue(t, state, "id"); !id.Equal(stringValue("x.b")) {
+		t.Errorf("id %s, want x.b", id)
+	}
+	if label := attrValue(t, state, "label"); !label.Equal(stringValue("b")) {
+		t.Errorf("label %s, want b", label)
+	}
+	srv.failPatch = false
+	p.read("kaiak_x", state)
+}
+
+func TestAttributelessResource(t *testing.T) {
+	status := attribute("status", "string")
+	status.ReadOnly = true
+	srv := newTestServer(t, resourceMeta{Name: "x"}, resourceMeta{Name: "y", Attributes: []attributeMeta{status}})
+	srv.defaults["status"] = "ready"
+	p := newTestProvider(t, srv, nil)
+
+	// An instance without attributes is created, read and destroyed
+	state := p.apply("kaiak_x", tftypes.Value{}, map[string]tftypes.Value{"label": stringValue("a")})
+	if got := srv.state("x.a"); got == nil {
+		t.Fatal("instance x.a not created")
+	}
+	if refreshed := p.read("kaiak_x", state); !refreshed.Equal(state) {
+		t.Errorf("refreshed state %s, want %s", refreshed, state)
+	}
+	p.destroy("kaiak_x", state)
+	if srv.state("x.a") != nil {
+		t.Error("instance x.a not destroyed")
+	}
+
+	// An instance with only read-only attributes reads them from the server
+	state = p.apply("kaiak_y", tftypes.Value{}, map[string]tftypes.Value{"label": stringValue("a")})
+	if v := attrValue(t, state, "status"); !v.Equal(stringValue("ready")) {
+		t.Errorf("status %s, want ready", v)
+	}
+	p.destroy("kaiak_y", state)
+}
+
+func BenchmarkTfListToKaiak(b *testing.B) {
+	elems := make([]attr.Value, 10000)
+	for i := range elems {
+		elems[i] = types.StringValue(fmt.Sprintf("10.0.%d.%d/32", i/256, i%256))
+	}
+	list := types.ListValueMust(types.StringType, elems)
+
+	// Each converts the list and encodes it for the request body, as on apply
+	for _, bench := range []struct {
+		name    string
+		convert func(types.List, string) (any, error)
+	}{
+		{"typed", tfListToKaiak},
+		{"boxed", boxedListToKaiak},
+		{"reboxed", func(list types.List, t string) (any, error) {
+			// A typed slice boxed again, as when compared with server state
+			v, err := tfListToKaiak(list, t)
+			items, _ := kaiakItems(v)
+			return items, err
+		}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				v, err := bench.convert(list, "string")
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := json.Marshal(v); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestDefaultPrecedence(t *testing.T) {
+	srv := newTestServer(t, resourceMeta{Name: "x", Attributes: []attributeMeta{
+		attribute("from_config", "string"),
+		attribute("from_provider", "string"),
+		attribute("from_server", "string"),
+		attribute("unset", "string"),
+		attribute("tls.min", "string"),
+	}})
+	srv.defaults = schema.State{"from_config": "server", "from_provider": "server", "from_server": "server", "tls.min": "server"}
+	defaults := tftypes.Map{ElementType: tftypes.String}
+	p := newTestProvider(t, srv, map[string]tftypes.Value{
+		"default_attributes": tftypes.NewValue(tftypes.Map{ElementType: defaults}, map[string]tftypes.Value{
+			"x": tftypes.NewValue(defaults, map[string]tftypes.Value{
+				"from_config":   stringValue("provider"),
+				"from_provider": stringValue("provider"),
+				"tls.min":       stringValue("provider"),
+			}),
+		}),
+	})
+
+	// Configuration wins over the provider default, which wins over the
+	// server default. A default for a block member only applies when the
+	// block is set
+	state := p.apply("kaiak_x", tftypes.Value{}, map[string]tftypes.Value{"from_config": stringValue("config")})
+	for name, want := range map[string]tftypes.Value{
+		"from_config":   stringValue("config"),
+		"from_provider": stringValue("provider"),
+		"from_server":   stringValue("server"),
+		"unset":         tftypes.NewValue(tftypes.String, nil),
+	} {
+		if got := attrValue(t, state, name); !got.Equal(want) {
+			t.Errorf("%s = %s, want %s", name, got, want)
+		}
+	}
+	if got := attrValue(t, state, "tls", "min"); !got.Equal(stringValue("server")) {
+		t.Errorf("tls.min = %s, want the server default", got)
+	}
+	var id string
+	attrValue(t, state, "id").As(&id)
+	if got := srv.state(id); got["from_provider"] != "provider" {
+		t.Errorf("server state %v, want the provider default sent", got)
+	}
+
+	// The provider default is planned, and the server default kept from state
+	planned := p.plan("kaiak_x", state, map[string]tftypes.Value{})
+	for name, want := range map[string]tftypes.Value{
+		"from_config":   stringValue("provider"),
+		"from_provider": stringValue("provider"),
+		"from_server":   stringValue("server"),
+	} {
+		if got := attrValue(t, planned, name); !got.Equal(want) {
+			t.Errorf("planned %s = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestEmptyAsNull(t *testing.T) {
+	declared := attribute("comment", "string")
+	declared.EmptyAsNull = true
+	for name, setting := range map[string]struct {
+		attr     attributeMeta
+		provider map[string]tftypes.Value
+	}{
+		"metadata": {attr: declared},
+		"provider": {attr: attribute("comment", "string"), provider: map[string]tftypes.Value{
+			"empty_as_null": tftypes.NewValue(tftypes.Map{ElementType: tftypes.List{ElementType: tftypes.String}}, map[string]tftypes.Value{
+				"x": tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{stringValue("comment")}),
+			}),
+		}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			srv := newTestServer(t, resourceMeta{Name: "x", Attributes: []attributeMeta{setting.attr}})
+			p := newTestProvider(t, srv, setting.provider)
+			empty := map[string]tftypes.Value{"label": stringValue("a"), "comment": stringValue("")}
+
+			// An empty string is not sent on create, and stays empty in state
+			state := p.apply("kaiak_x", tftypes.Value{}, empty)
+			if got := srv.state("x.a"); got == nil {
+				t.Fatal("instance not created")
+			} else if _, ok := got["comment"]; ok {
+				t.Errorf("server state %v, want comment unset", got)
+			}
+			if got := attrValue(t, state, "comment"); !got.Equal(stringValue("")) {
+				t.Errorf("comment %s, want empty", got)
+			}
+
+			// Setting a value and then an empty string clears it on the server
+			state = p.apply("kaiak_x", state, map[string]tftypes.Value{"label": stringValue("a"), "comment": stringValue("text")})
+			if got := srv.state("x.a")["comment"]; got != "text" {
+				t.Errorf("comment %v on the server, want text", got)
+			}
+			state = p.apply("kaiak_x", state, empty)
+			if _, ok := srv.state("x.a")["comment"]; ok {
+				t.Errorf("server state %v, want comment cleared", srv.state("x.a"))
+			}
+
+			// A refresh keeps an empty string in state when the server has
+			// none, and null when the server has an empty string
+			if got := attrValue(t, p.read("kaiak_x", state), "comment"); !got.Equal(stringValue("")) {
+				t.Errorf("refreshed comment %s, want empty", got)
+			}
+			state = p.apply("kaiak_x", tftypes.Value{}, map[string]tftypes.Value{"label": stringValue("b")})
+			if got := attrValue(t, state, "comment"); !got.IsNull() {
+				t.Fatalf("comment %s, want null", got)
+			}
+			srv.Lock()
+			srv.instances["x.b"]["comment"] = ""
+			srv.Unlock()
+			state = p.read("kaiak_x", state)
+			if got := attrValue(t, state, "comment"); !got.IsNull() {
+				t.Errorf("refreshed comment %s, want null", got)
+			}
+		})
+	}
+}
+
+func TestOptionalBlockDefaults(t *testing.T) {
+	srv := newTestServer(t, resourceMeta{Name: "x", Attributes: []attributeMeta{attribute("tls.min", "string"), attribute("tls.cert", "string")}})
+	srv.defaults = schema.State{"tls.min": "1.2"}
+	p := newTestProvider(t, srv, nil)
+	tlsType := p.schemas["kaiak_x"].ValueType().(tftypes.Object).AttributeTypes["tls"].(tftypes.Object)
+	null := tftypes.NewValue(tftypes.String, nil)
+	emptyBlock := tftypes.NewValue(tlsType, map[string]tftypes.Value{"min": null, "cert": null})
+
+	for name, config := range map[string]map[string]tftypes.Value{
+		"unset": {},
+		"empty": {"tls": emptyBlock},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// The server default is read back, and the apply is consistent
+			// with the plan
+			state := p.apply("kaiak_x", tftypes.Value{}, config)
+			if got := attrValue(t, state, "tls", "min"); !got.Equal(stringValue("1.2")) {
+				t.Errorf("tls.min %s, want the server default", got)
+			}
+			if got := attrValue(t, state, "tls", "cert"); !got.IsNull() {
+				t.Errorf("tls.cert %s, want null", got)
+			}
+
+			// A following plan has no changes
+			if planned := p.plan("kaiak_x", state, config); !planned.Equal(state) {
+				t.Errorf("planned %s, want no changes from %s", planned, state)
+			}
+		})
+	}
+
+	// A block configured without members stays present when the server has
+	// no defaults for them
+	srv.defaults = schema.State{}
+	state := p.apply("kaiak_x", tftypes.Value{}, map[string]tftypes.Value{"tls": emptyBlock})
+	if got := attrValue(t, state, "tls"); got.IsNull() {
+		t.Error("tls is null, want the block present")
+	}
+	if planned := p.plan("kaiak_x", state, map[string]tftypes.Value{"tls": emptyBlock}); !planned.Equal(state) {
+		t.Errorf("planned %s, want no changes from %s", planned, state)
+	}
+}
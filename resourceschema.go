@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	// Packages
+	datasource "github.com/hashicorp/terraform-plugin-framework/datasource"
+	tfschema "github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	types "github.com/hashicorp/terraform-plugin-framework/types"
+	schema "github.com/mutablelogic/go-server/pkg/provider/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// resourceSchemaDataSource implements the kaiak_resource_schema data source.
+type resourceSchemaDataSource struct {
+	data *providerData
+}
+
+// resourceSchemaDataSourceModel maps the data source schema to Go types.
+type resourceSchemaDataSourceModel struct {
+	Type       types.String                     `tfsdk:"type"`
+	Attributes []schemaAttributeDataSourceModel `tfsdk:"attributes"`
+}
+
+// schemaAttributeDataSourceModel describes an attribute of the resource type.
+type schemaAttributeDataSourceModel struct {
+	Name          types.String `tfsdk:"name"`
+	TerraformName types.String `tfsdk:"terraform_name"`
+	Type          types.String `tfsdk:"type"`
+	Description   types.String `tfsdk:"description"`
+	Required      types.Bool   `tfsdk:"required"`
+	ReadOnly      types.Bool   `tfsdk:"readonly"`
+	Sensitive     types.Bool   `tfsdk:"sensitive"`
+}
+
+var _ datasource.DataSource = (*resourceSchemaDataSource)(nil)
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+func NewResourceSchemaDataSource() datasource.DataSource {
+	return &resourceSchemaDataSource{}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// DATA SOURCE INTERFACE
+
+func (d *resourceSchemaDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_resource_schema"
+}
+
+func (d *resourceSchemaDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = tfschema.Schema{
+		Description: "Describes the attributes of a resource type on a running Kaiak server.",
+		Attributes: map[string]tfschema.Attribute{
+			"type": tfschema.StringAttribute{
+				Description: "Resource type name (e.g. \"httpserver\").",
+				Required:    true,
+			},
+			"attributes": tfschema.ListNestedAttribute{
+				Description: "Attributes of the resource type, as reported by the server.",
+				Computed:    true,
+				NestedObject: tfschema.NestedAttributeObject{
+					Attributes: map[string]tfschema.Attribute{
+						"name": tfschema.StringAttribute{
+							Description: "Attribute name on the server (e.g. \"tls.cert\").",
+							Computed:    true,
+						},
+						"terraform_name": tfschema.StringAttribute{
+							Description: "Attribute name in Terraform configuration, with the block name for " +
+								"an attribute in a nested block (e.g. \"tls.cert\").",
+							Computed: true,
+						},
+						"type": tfschema.StringAttribute{
+							Description: "Attribute type on the server (e.g. \"string\" or \"[]int\").",
+							Computed:    true,
+						},
+						"description": tfschema.StringAttribute{
+							Description: "Attribute description.",
+							Computed:    true,
+						},
+						"required": tfschema.BoolAttribute{
+							Description: "Whether the attribute must be set.",
+							Computed:    true,
+						},
+						"readonly": tfschema.BoolAttribute{
+							Description: "Whether the attribute is set by the server and cannot be configured.",
+							Computed:    true,
+						},
+						"sensitive": tfschema.BoolAttribute{
+							Description: "Whether the attribute holds a secret.",
+							Computed:    true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *resourceSchemaDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	data, ok := req.ProviderData.(*providerData)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected provider data type",
+			fmt.Sprintf("Expected *providerData, got %T", req.ProviderData))
+		return
+	}
+	d.data = data
+}
+
+func (d *resourceSchemaDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.data == nil {
+		resp.Diagnostics.AddError("Data source not configured",
+			"The provider has not been configured. Ensure the provider block is present and valid.")
+		return
+	}
+
+	var config resourceSchemaDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &config)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if config.Type.IsUnknown() {
+		resp.Diagnostics.AddError("Unknown type",
+			"The \"type\" attribute is not yet known. This data source cannot be read during plan "+
+				"when the type depends on another resource's output.")
+		return
+	}
+
+	// Discover every resource type, so a missing type can be reported with those available
+	resourceType := config.Type.ValueString()
+	result, err := discoverResources(ctx, d.data.clientFor(resourceType), schema.ListResourcesRequest{})
+	if err != nil {
+		resp.Diagnostics.AddError("Failed to list resources", err.Error())
+		return
+	}
+	i := slices.IndexFunc(result.Resources, func(meta resourceMeta) bool {
+		return meta.Name == resourceType
+	})
+	if i < 0 {
+		names := make([]string, 0, len(result.Resources))
+		for _, meta := range result.Resources {
+			names = append(names, meta.Name)
+		}
+		slices.Sort(names)
+		resp.Diagnostics.AddError("Resource type not found",
+			fmt.Sprintf("The server has no resource type %q. Available resource types: %s.",
+				resourceType, strings.Join(names, ", ")))
+		return
+	}
+
+	// Map the attributes into the model
+	attrs := d.data.schema.apply(result.Resources[i]).Attributes
+	config.Attributes = make([]schemaAttributeDataSourceModel, 0, len(attrs))
+	for _, a := range attrs {
+		config.Attributes = append(config.Attributes, schemaAttributeDataSourceModel{
+			Name:          types.StringValue(a.Name),
+			TerraformName: types.StringValue(newAttrInfo(a).path().String()),
+			Type:          types.StringValue(a.Type),
+			Description:   types.StringValue(a.Description),
+			Required:      types.BoolValue(a.Required),
+			ReadOnly:      types.BoolValue(a.ReadOnly),
+			Sensitive:     types.BoolValue(a.Sensitive),
+		})
+	}
+
+	resp.Diagnostics.Append(resp.State.Set(ctx, &config)...)
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	// Packages
+	client "github.com/mutablelogic/go-client"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// retryingTransport repeats a request which fails with a server error, a
+// network error (unless disabled) or one of the additional status codes, up
+// to maxRetries times with exponential backoff. Every
+// retry takes a token from a budget shared by all clients, so that when the
+// server is broadly unhealthy, requests fail fast once the budget is spent
+// rather than each retrying in turn. Each attempt has its own timeout, so
+// that the delays between attempts do not count towards it.
+type retryingTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+	budget     *retryBudget
+	codes      []int         // status codes retried in addition to server errors
+	connErrors bool          // retry requests which fail without a response
+	timeout    time.Duration // limit of each attempt, including reading the response, or zero
+}
+
+// cancelBody is a response body which cancels the context of its attempt
+// when closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// retryBudget is a token bucket of retries, which holds up to size tokens
+// and regains one token each refill interval.
+type retryBudget struct {
+	mu     sync.Mutex
+	size   int
+	refill time.Duration
+	tokens int
+	last   time.Time
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	// defaultRetryBudget is the number of retries shared across all requests
+	defaultRetryBudget = 10
+
+	// defaultRetryBudgetRefill is the interval at which a retry is returned
+	// to the budget
+	defaultRetryBudgetRefill = 30 * time.Second
+
+	// minRetryDelay and maxRetryDelay bound the backoff between retries
+	minRetryDelay = 500 * time.Millisecond
+	maxRetryDelay = 10 * time.Second
+)
+
+// retryNow returns the time used to refill the retry budget. It can be
+// replaced to refill the budget with a fixed time.
+var retryNow = time.Now
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// newRetryBudget returns a full budget of size retries, regaining one each
+// refill interval.
+func newRetryBudget(size int, refill time.Duration) *retryBudget {
+	return &retryBudget{size: size, refill: refill, tokens: size, last: retryNow()}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// RoundTrip sends the request, repeating it while it fails with a retryable
+// error and retries remain. The body is buffered so that it can be sent
+// again.
+func (t *retryingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = data
+	}
+
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := req.Context(), context.CancelFunc(func() {})
+		if t.timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, t.timeout)
+		}
+		r := req.Clone(ctx)
+		if body != nil {
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		response, err := t.base.RoundTrip(r)
+		done := attempt >= t.maxRetries || !t.retryable(req, response, err)
+		if !done && !t.budget.take() {
+			logWarn(req.Context(), "Retry budget exhausted: not retrying request", map[string]interface{}{
+				"method": req.Method,
+				"path":   req.URL.Path,
+			})
+			done = true
+		}
+		if done {
+			// The attempt ends when its response body is closed
+			if response != nil {
+				response.Body = &cancelBody{ReadCloser: response.Body, cancel: cancel}
+			} else {
+				cancel()
+			}
+			return response, err
+		}
+
+		// Wait before retrying, giving up if the request is canceled
+		delay := retryDelay(attempt, response)
+		fields := map[string]interface{}{
+			"method":  req.Method,
+			"path":    req.URL.Path,
+			"attempt": attempt + 1,
+			"delay":   delay.String(),
+		}
+		if err != nil {
+			fields["error"] = err.Error()
+		} else {
+			fields["status"] = response.StatusCode
+			io.Copy(io.Discard, response.Body)
+			response.Body.Close()
+		}
+		cancel()
+		logWarn(req.Context(), "Request failed: retrying", fields)
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// Close closes the body and cancels the context of its attempt.
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// take removes a token from the budget, returning false if none remain.
+func (b *retryBudget) take() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Regain a token for each whole refill interval since the last
+	if now := retryNow(); b.tokens < b.size {
+		if regained := int(now.Sub(b.last) / b.refill); regained > 0 {
+			b.tokens = min(b.size, b.tokens+regained)
+			b.last = b.last.Add(time.Duration(regained) * b.refill)
+		}
+	} else {
+		b.last = now
+	}
+	if b.tokens == 0 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// retryable returns true if a request failed with a server error or one of
+// the additional status codes, or with a network error while the request
+// itself was not canceled and network errors are retried.
+func (t *retryingTransport) retryable(req *http.Request, response *http.Response, err error) bool {
+	if err != nil {
+		return t.connErrors && req.Context().Err() == nil
+	}
+	return response.StatusCode >= http.StatusInternalServerError || slices.Contains(t.codes, response.StatusCode)
+}
+
+// retryDelay returns the time to wait before a retry, doubling from
+// minRetryDelay with each attempt, or as long as the server asks in a
+// Retry-After header, and at most maxRetryDelay.
+func retryDelay(attempt int, response *http.Response) time.Duration {
+	delay := maxRetryDelay
+	if attempt < 5 {
+		delay = minRetryDelay << attempt
+	}
+	if response != nil {
+		if seconds, err := strconv.Atoi(response.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			delay = max(delay, time.Duration(seconds)*time.Second)
+		}
+	}
+	return min(delay, maxRetryDelay)
+}
+
+// optRetry repeats failed requests up to maxRetries times, taking each
+// retry from the shared budget. Responses with the given status codes are
+// retried as well as server errors, and requests which fail without a
+// response when connErrors is set. The client timeout would include the
+// delays between attempts, so it limits each attempt instead.
+func optRetry(maxRetries int, budget *retryBudget, codes []int, connErrors bool) client.ClientOpt {
+	return func(c *client.Client) error {
+		c.Transport = &retryingTransport{base: c.Transport, maxRetries: maxRetries, budget: budget, codes: codes, connErrors: connErrors, timeout: c.Timeout}
+		c.Timeout = 0
+		return nil
+	}
+}
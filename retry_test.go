@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	// Packages
+	client "github.com/mutablelogic/go-client"
+)
+
+func TestRetryTimeout(t *testing.T) {
+	// The first attempt hangs, and the next fails, so the delays between
+	// attempts add up to longer than the client timeout
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch attempts.Add(1) {
+		case 1:
+			select {
+			case <-req.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"ok": true}`))
+		}
+	}))
+	defer srv.Close()
+
+	timeout := 300 * time.Millisecond
+	cl, err := client.New(client.OptEndpoint(srv.URL), client.OptTimeout(timeout),
+		optRetry(2, newRetryBudget(10, time.Minute), nil, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cl.Timeout != 0 {
+		t.Errorf("client timeout %v, want it replaced by a timeout for each attempt", cl.Timeout)
+	}
+
+	var response struct {
+		OK bool `json:"ok"`
+	}
+	start := time.Now()
+	if err := cl.DoWithContext(context.Background(), nil, &response); err != nil {
+		t.Fatal(err)
+	}
+	if !response.OK || attempts.Load() != 3 {
+		t.Errorf("response %v after %d attempts, want ok after 3", response, attempts.Load())
+	}
+	if elapsed := time.Since(start); elapsed < minRetryDelay*3 {
+		t.Errorf("took %v, want the delays between attempts", elapsed)
+	}
+}
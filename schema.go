@@ -1,20 +1,41 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	// Packages
+	int64validator "github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	listvalidator "github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	mapvalidator "github.com/hashicorp/terraform-plugin-framework-validators/mapvalidator"
+	resourcevalidator "github.com/hashicorp/terraform-plugin-framework-validators/resourcevalidator"
+	stringvalidator "github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	attr "github.com/hashicorp/terraform-plugin-framework/attr"
 	diag "github.com/hashicorp/terraform-plugin-framework/diag"
+	path "github.com/hashicorp/terraform-plugin-framework/path"
+	resource "github.com/hashicorp/terraform-plugin-framework/resource"
 	tfschema "github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	boolplanmodifier "github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	float64planmodifier "github.com/hashicorp/terraform-plugin-framework/resource/schema/float64planmodifier"
+	int64planmodifier "github.com/hashicorp/terraform-plugin-framework/resource/schema/int64planmodifier"
+	listplanmodifier "github.com/hashicorp/terraform-plugin-framework/resource/schema/listplanmodifier"
+	mapplanmodifier "github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
+	objectplanmodifier "github.com/hashicorp/terraform-plugin-framework/resource/schema/objectplanmodifier"
 	planmodifier "github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	stringplanmodifier "github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	validator "github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	types "github.com/hashicorp/terraform-plugin-framework/types"
-	tflog "github.com/hashicorp/terraform-plugin-log/tflog"
-	schema "github.com/mutablelogic/go-server/pkg/provider/schema"
+	basetypes "github.com/hashicorp/terraform-plugin-framework/types/basetypes"
 )
 
 ///////////////////////////////////////////////////////////////////////////////
@@ -22,49 +43,241 @@ import (
 
 // attrInfo maps a single kaiak attribute to its terraform representation.
 type attrInfo struct {
-	kaiakName string           // original kaiak name, e.g. "tls.cert"
-	tfBlock   string           // terraform block name, empty for top-level
-	tfField   string           // field name within block (or top-level name)
-	attr      schema.Attribute // original kaiak attribute metadata
+	kaiakName string        // original kaiak name, e.g. "tls.cert"
+	tfBlock   string        // terraform block name, with enclosing blocks (e.g. "tls.client"), empty for top-level
+	tfField   string        // field name within block (or top-level name)
+	attr      attributeMeta // original kaiak attribute metadata
+	alias     bool          // a deprecated former name of the kaiak attribute
 }
 
+// timeFormatKey is the context key for the representation of time values.
+type timeFormatKey struct{}
+
+// schemaSettings are the provider settings which change how the schemas of
+// resource types are built, from the environment.
+type schemaSettings struct {
+	descriptions map[string]string   // resource type → description
+	groups       map[string][]string // resource type → prefixes of attributes grouped into blocks
+	unique       map[string][]string // resource type → list attributes with unique elements
+	separator    string              // separator of the blocks and field in attribute names, or empty for a dot
+	strictTypes  bool                // an attribute of unknown type fails the schema
+	blockExtras  bool                // each block captures members from the server missing from the schema
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// knownTypes are the scalar kaiak types the provider understands. Lists and
+// maps of these are also understood. Any other type is represented as a
+// string, which may lose fidelity.
+var knownTypes = map[string]bool{
+	"string":   true,
+	"bool":     true,
+	"int":      true,
+	"uint":     true,
+	"float":    true,
+	"duration": true,
+	"time":     true,
+	"ref":      true,
+}
+
+// defaultAttributeSeparator separates the block and field of a nested
+// attribute name, unless attribute_separator is set.
+const defaultAttributeSeparator = "."
+
+// blockSeparator separates the names of nested blocks in the block name of
+// an attribute (e.g. "tls.client" for the block "client" within "tls").
+const blockSeparator = "."
+
+// groupSeparator follows the prefix of an attribute name which is grouped
+// into a block by attribute_groups (e.g. "log_level" in block "log").
+const groupSeparator = "_"
+
+// blockExtraField is the computed member of each block which holds block
+// members from the server missing from the schema, when block_extras is
+// set.
+const blockExtraField = "extra"
+
+// Representations of time values in state
+const (
+	timeFormatRFC3339 = "rfc3339"
+	timeFormatUnix    = "unix"
+)
+
+// replaceComparators decide, from the prior and planned values of an
+// attribute which changed, whether the change requires replacing the
+// instance. The types are those each comparator applies to, or nil for any.
+var replaceComparators = map[string]struct {
+	compare func(prior, planned attr.Value) bool
+	types   func(t string) bool
+	desc    string
+}{
+	"always": {
+		compare: func(_, _ attr.Value) bool { return true },
+		desc:    "Changing this attribute replaces the instance.",
+	},
+	"decrease": {
+		compare: func(prior, planned attr.Value) bool {
+			p, ok1 := numberValue(prior)
+			n, ok2 := numberValue(planned)
+			return ok1 && ok2 && n < p
+		},
+		types: isNumberType,
+		desc:  "Decreasing this attribute replaces the instance.",
+	},
+	"increase": {
+		compare: func(prior, planned attr.Value) bool {
+			p, ok1 := numberValue(prior)
+			n, ok2 := numberValue(planned)
+			return ok1 && ok2 && n > p
+		},
+		types: isNumberType,
+		desc:  "Increasing this attribute replaces the instance.",
+	},
+	"remove": {
+		compare: removesElements,
+		types: func(t string) bool {
+			return strings.HasPrefix(t, "[]") || strings.HasPrefix(t, "map[")
+		},
+		desc: "Removing an element of this attribute replaces the instance.",
+	},
+}
+
+// warnedTypes records the unknown types already warned about, so each is
+// only logged once.
+var warnedTypes sync.Map
+
 ///////////////////////////////////////////////////////////////////////////////
 // PUBLIC METHODS
 
+// path returns the terraform attribute path for the attribute, which is
+// nested within its block when it has one.
+func (info attrInfo) path() path.Path {
+	if info.tfBlock != "" {
+		return blockPath(info.tfBlock).AtName(info.tfField)
+	}
+	return path.Root(info.tfField)
+}
+
+// blockPath returns the terraform attribute path for a block, which is
+// nested within the blocks enclosing it.
+func blockPath(block string) path.Path {
+	names := strings.Split(block, blockSeparator)
+	p := path.Root(names[0])
+	for _, name := range names[1:] {
+		p = p.AtName(name)
+	}
+	return p
+}
+
+// parentBlock returns the name of the block enclosing a block, and its own
+// name, or an empty string for a top-level block.
+func parentBlock(block string) (string, string) {
+	if i := strings.LastIndex(block, blockSeparator); i >= 0 {
+		return block[:i], block[i+len(blockSeparator):]
+	}
+	return "", block
+}
+
+// blockNames returns the names of the blocks holding the attributes, and
+// the blocks enclosing them, with each block before those nested within it.
+func blockNames(infos []attrInfo) []string {
+	seen := map[string]bool{}
+	for _, info := range infos {
+		for block := info.tfBlock; block != "" && !seen[block]; block, _ = parentBlock(block) {
+			seen[block] = true
+		}
+	}
+	names := slices.Collect(maps.Keys(seen))
+	slices.SortFunc(names, func(a, b string) int {
+		return cmp.Or(cmp.Compare(strings.Count(a, blockSeparator), strings.Count(b, blockSeparator)), strings.Compare(a, b))
+	})
+	return names
+}
+
 // buildResourceSchema converts kaiak resource attributes into a terraform
-// resource schema. Dotted attribute names (e.g. "tls.cert") are grouped
-// into SingleNestedAttribute blocks. The fixed "name" and "id" attributes
-// are prepended.
-func buildResourceSchema(resourceName string, kaiakAttrs []schema.Attribute) (tfschema.Schema, []attrInfo, diag.Diagnostics) {
+// resource schema. Nested attribute names (e.g. "tls.cert") are grouped
+// into SingleNestedAttribute blocks, which are themselves nested for names
+// with more than one separator (e.g. "tls.client.cert"). The fixed "id", "last_applied",
+// "refresh_attributes" and "merge_blocks" attributes are prepended.
+func buildResourceSchema(meta resourceMeta) (tfschema.Schema, []attrInfo, diag.Diagnostics) {
 	var diags diag.Diagnostics
+	resourceName, kaiakAttrs := meta.Name, meta.Attributes
 
 	// Build attrInfo list and detect naming collisions. Two kaiak
-	// attributes could map to the same terraform field when dots are
-	// converted to underscores (e.g. "tls.cert_key" and "tls.cert.key"
-	// both become block "tls", field "cert_key").
-	var infos []attrInfo
-	seen := map[string]string{}  // "block/field" → original kaiak name
-	reserved := map[string]bool{ // top-level names reserved for internal use
-		"id": true,
+	// attributes could map to the same terraform field when one is grouped
+	// into a block (e.g. "log_level" grouped into block "log", and
+	// "log.level").
+	infos := make([]attrInfo, 0, len(kaiakAttrs)) // non-nil, so an empty result is cached
+	seen := map[string]string{}                   // "block/field" → original kaiak name
+	reserved := map[string]bool{                  // top-level names reserved for internal use
+		"id":                 true,
+		"last_applied":       true,
+		"refresh_attributes": true,
+		"merge_blocks":       true,
 	}
-	for _, a := range kaiakAttrs {
-		info := newAttrInfo(a)
+	add := func(info attrInfo, name string) {
 		if info.tfBlock == "" && reserved[info.tfField] {
 			diags.AddError("Reserved attribute name",
 				fmt.Sprintf("Resource %q: attribute %q conflicts with reserved terraform attribute %q",
-					resourceName, a.Name, info.tfField))
-			continue
+					resourceName, name, info.tfField))
+			return
 		}
 		key := info.tfBlock + "/" + info.tfField
 		if prev, ok := seen[key]; ok {
 			diags.AddError("Attribute naming collision",
 				fmt.Sprintf("Resource %q: attributes %q and %q both map to terraform field %q (block %q)",
-					resourceName, prev, a.Name, info.tfField, info.tfBlock))
-			continue
+					resourceName, prev, name, info.tfField, info.tfBlock))
+			return
 		}
-		seen[key] = a.Name
+		seen[key] = name
 		infos = append(infos, info)
 	}
+	for _, a := range kaiakAttrs {
+		if a.Derived && !a.ReadOnly {
+			// The server derives the value, so it cannot be set in configuration
+			if a.Required {
+				diags.AddWarning("Inconsistent attribute metadata",
+					fmt.Sprintf("Resource %q: attribute %q is both required and derived by the server. It is treated as read-only.",
+						resourceName, a.Name))
+			}
+			a.ReadOnly, a.Required = true, false
+		}
+		if a.Required && a.ReadOnly {
+			// A required attribute cannot also be computed, so the server sets it
+			diags.AddWarning("Inconsistent attribute metadata",
+				fmt.Sprintf("Resource %q: attribute %q is both required and read-only. It is treated as read-only.",
+					resourceName, a.Name))
+			a.Required = false
+		}
+		if a.WriteOnly {
+			a = writeOnlyAttr(resourceName, a, &diags)
+		}
+		info := newAttrInfo(a)
+		if meta.StrictTypes && !isKnownType(a.Type) {
+			diags.AddError("Unknown attribute type",
+				fmt.Sprintf("Resource %q: attribute %q has type %q, which this provider version does not support. "+
+					"Upgrade the provider, or unset strict_types to represent it as a string.",
+					resourceName, a.Name, a.Type))
+			continue
+		}
+		if err := checkConstraints(a); err != nil {
+			diags.AddError("Invalid attribute constraint",
+				fmt.Sprintf("Resource %q: attribute %q: %s", resourceName, a.Name, err))
+			continue
+		}
+		add(info, a.Name)
+
+		// Former names of a renamed attribute are accepted in configuration,
+		// with a deprecation warning, and written to the server as the new name
+		for _, alias := range a.Aliases {
+			renamed := a
+			renamed.Name = alias
+			aliasInfo := newAttrInfo(renamed)
+			aliasInfo.kaiakName, aliasInfo.attr, aliasInfo.alias = a.Name, a, true
+			add(aliasInfo, alias)
+		}
+	}
 
 	if diags.HasError() {
 		return tfschema.Schema{}, nil, diags
@@ -79,72 +292,255 @@ func buildResourceSchema(resourceName string, kaiakAttrs []schema.Attribute) (tf
 				stringplanmodifier.UseStateForUnknown(),
 			},
 		},
+		"last_applied": tfschema.StringAttribute{
+			Description: "Time (RFC3339) at which Terraform last created or updated the instance.",
+			Computed:    true,
+		},
+		"refresh_attributes": tfschema.ListAttribute{
+			Description: "When set, a refresh only updates these attributes (top-level names or block names) " +
+				"from the server, and all other attributes keep their prior state.",
+			ElementType: types.StringType,
+			Optional:    true,
+		},
+		"merge_blocks": tfschema.BoolAttribute{
+			Description: "When true, block members which are not set in configuration keep their current " +
+				"server value on update, rather than being cleared. Defaults to false.",
+			Optional: true,
+		},
 	}
 
-	// Group block members by prefix
+	// Group block members by prefix, with a (possibly empty) group for
+	// each enclosing block
 	blocks := map[string]map[string]tfschema.Attribute{}
+	for _, name := range blockNames(infos) {
+		blocks[name] = map[string]tfschema.Attribute{}
+	}
 
 	for _, info := range infos {
-		tfAttr := kaiakAttrToTF(info.attr)
+		a := info.attr
+		if len(a.Aliases) > 0 {
+			// Either the attribute or one of its aliases may be set
+			a.Required = false
+		}
+		if info.alias {
+			a.Deprecated = fmt.Sprintf("This attribute was renamed on the server. Use %q instead.", newAttrInfo(a).path())
+		}
+		tfAttr := kaiakAttrToTF(a)
 		if info.tfBlock != "" {
-			if blocks[info.tfBlock] == nil {
-				blocks[info.tfBlock] = map[string]tfschema.Attribute{}
-			}
 			blocks[info.tfBlock][info.tfField] = tfAttr
 		} else {
 			tfAttrs[info.tfField] = tfAttr
 		}
 	}
 
-	// Convert grouped block members to SingleNestedAttribute.
-	// Mark the block Required when any nested attribute is required.
-	for blockName, blockAttrs := range blocks {
-		required := false
-		for _, a := range blockAttrs {
-			switch ta := a.(type) {
-			case tfschema.StringAttribute:
-				if ta.Required {
-					required = true
-				}
-			case tfschema.BoolAttribute:
-				if ta.Required {
-					required = true
-				}
-			case tfschema.Int64Attribute:
-				if ta.Required {
-					required = true
-				}
-			case tfschema.Float64Attribute:
-				if ta.Required {
-					required = true
-				}
-			case tfschema.ListAttribute:
-				if ta.Required {
-					required = true
-				}
-			case tfschema.MapAttribute:
-				if ta.Required {
-					required = true
-				}
+	// Convert grouped block members to SingleNestedAttribute, each nested
+	// block before the block enclosing it. Mark the block Required when any
+	// member is required, and Computed only when no member can be configured.
+	names := blockNames(infos)
+	for i := len(names) - 1; i >= 0; i-- {
+		blockName, blockAttrs := names[i], blocks[names[i]]
+		if len(blockAttrs) == 0 {
+			continue // never emit a block without members
+		}
+
+		// Capture block members from the server which are missing from the schema
+		if _, exists := blockAttrs[blockExtraField]; meta.BlockExtras && !exists {
+			blockAttrs[blockExtraField] = tfschema.MapAttribute{
+				Description: "Members of the block returned by the server which are not in the schema, such as " +
+					"those added in a server upgrade, with values other than strings encoded as JSON.",
+				ElementType: types.StringType,
+				Computed:    true,
+				PlanModifiers: []planmodifier.Map{
+					mapplanmodifier.UseStateForUnknown(),
+				},
 			}
 		}
-		tfAttrs[blockName] = tfschema.SingleNestedAttribute{
+
+		parent, name := parentBlock(blockName)
+		parentAttrs := tfAttrs
+		if parent != "" {
+			parentAttrs = blocks[parent]
+		}
+		if _, exists := parentAttrs[name]; exists {
+			diags.AddError("Attribute naming collision",
+				fmt.Sprintf("Resource %q: block %q has the same name as an attribute", resourceName, blockName))
+			continue
+		}
+		required, configurable := false, false
+		for _, a := range blockAttrs {
+			required = required || a.IsRequired()
+			configurable = configurable || a.IsRequired() || a.IsOptional()
+		}
+		block := tfschema.SingleNestedAttribute{
 			Attributes: blockAttrs,
 			Required:   required,
-			Optional:   !required,
+			Optional:   configurable && !required,
 			Computed:   !required, // server may populate defaults for optional blocks
 		}
+		if block.Computed {
+			// Reuse the server-populated block from prior state rather than
+			// planning "(known after apply)" on every run
+			block.PlanModifiers = []planmodifier.Object{
+				objectplanmodifier.UseStateForUnknown(),
+			}
+		}
+		parentAttrs[name] = block
+	}
+
+	// Report an invalid combination of flags here, rather than leaving the
+	// framework to reject the schema
+	for name, a := range tfAttrs {
+		checkSchemaFlags(resourceName, name, a, &diags)
+	}
+	if diags.HasError() {
+		return tfschema.Schema{}, nil, diags
 	}
 
 	return tfschema.Schema{
-		Description: fmt.Sprintf("Manages a %s resource instance on a running Kaiak server.", resourceName),
-		Attributes:  tfAttrs,
+		Description:         fmt.Sprintf("Manages a %s resource instance on a running Kaiak server.", resourceName),
+		MarkdownDescription: fmt.Sprintf("Manages a `%s` resource instance on a running Kaiak server.", resourceName),
+		Attributes:          tfAttrs,
 	}, infos, diags
 }
 
+// writeOnlyAttr returns the attribute as it is declared when the server
+// marks it write-only. Its value is sensitive, and it has no former names,
+// as an alias would be planned from it. An attribute which is also
+// read-only, or is a member of a block, which Terraform does not allow to
+// be write-only, is not write-only, with a warning.
+func writeOnlyAttr(resourceName string, a attributeMeta, diags *diag.Diagnostics) attributeMeta {
+	switch {
+	case a.ReadOnly:
+		diags.AddWarning("Inconsistent attribute metadata",
+			fmt.Sprintf("Resource %q: attribute %q is both read-only and write-only. It is treated as read-only.",
+				resourceName, a.Name))
+		a.WriteOnly = false
+	case newAttrInfo(a).tfBlock != "":
+		diags.AddWarning("Unsupported write-only attribute",
+			fmt.Sprintf("Resource %q: attribute %q is write-only, which is not supported for a member of a block. "+
+				"It is stored in state as a sensitive value.", resourceName, a.Name))
+		a.WriteOnly, a.Sensitive = false, true
+	default:
+		if len(a.Aliases) > 0 {
+			diags.AddWarning("Unsupported write-only attribute",
+				fmt.Sprintf("Resource %q: attribute %q is write-only, so its former names %s are not accepted.",
+					resourceName, a.Name, strings.Join(a.Aliases, ", ")))
+		}
+		a.Sensitive, a.Aliases = true, nil
+	}
+	return a
+}
+
+// checkSchemaFlags adds an error for an attribute, or a member of a block
+// at any depth, with an invalid combination of flags.
+func checkSchemaFlags(resourceName, name string, a tfschema.Attribute, diags *diag.Diagnostics) {
+	if err := checkFlags(a); err != nil {
+		diags.AddError("Invalid attribute schema",
+			fmt.Sprintf("Resource %q: attribute %q: %s", resourceName, name, err))
+	}
+	if block, ok := a.(tfschema.SingleNestedAttribute); ok {
+		for field, member := range block.Attributes {
+			checkSchemaFlags(resourceName, name+"."+field, member, diags)
+		}
+	}
+}
+
+// checkFlags returns an error if an attribute is neither required, optional
+// nor computed, or is required as well as optional or computed.
+func checkFlags(a tfschema.Attribute) error {
+	switch {
+	case a.IsRequired() && (a.IsOptional() || a.IsComputed()):
+		return errors.New("cannot be required as well as optional or computed")
+	case !a.IsRequired() && !a.IsOptional() && !a.IsComputed():
+		return errors.New("must be required, optional or computed")
+	}
+	return nil
+}
+
+// relationValidators returns resource config validators for the attribute
+// relationships in the server metadata: attributes which must be set
+// together, and attributes which cannot be set together. Relationships
+// naming an unknown attribute are ignored. An attribute cannot be set
+// together with its aliases, and one of them must be set when the attribute
+// is required.
+func relationValidators(infos []attrInfo) []resource.ConfigValidator {
+	paths := make(map[string]path.Expression, len(infos))
+	aliases := map[string][]path.Expression{}
+	for _, info := range infos {
+		if info.alias {
+			aliases[info.kaiakName] = append(aliases[info.kaiakName], info.path().Expression())
+		} else {
+			paths[info.kaiakName] = info.path().Expression()
+		}
+	}
+	resolve := func(name string, others []string) []path.Expression {
+		exprs := []path.Expression{paths[name]}
+		for _, other := range others {
+			if expr, ok := paths[other]; ok {
+				exprs = append(exprs, expr)
+			}
+		}
+		return exprs
+	}
+
+	var validators []resource.ConfigValidator
+	for _, info := range infos {
+		if info.alias {
+			continue
+		}
+		if exprs := append([]path.Expression{paths[info.kaiakName]}, aliases[info.kaiakName]...); len(exprs) > 1 {
+			if info.attr.Required {
+				validators = append(validators, resourcevalidator.ExactlyOneOf(exprs...))
+			} else {
+				validators = append(validators, resourcevalidator.Conflicting(exprs...))
+			}
+		}
+		if exprs := resolve(info.kaiakName, info.attr.RequiredWith); len(exprs) > 1 {
+			validators = append(validators, resourcevalidator.RequiredTogether(exprs...))
+		}
+		if exprs := resolve(info.kaiakName, info.attr.ConflictsWith); len(exprs) > 1 {
+			validators = append(validators, resourcevalidator.Conflicting(exprs...))
+		}
+	}
+	return validators
+}
+
 ///////////////////////////////////////////////////////////////////////////////
 // ATTRIBUTE TYPE HELPERS
 
+// isKnownType returns true if the kaiak type, and the element (and key)
+// type of a list or map, is a known type.
+func isKnownType(t string) bool {
+	switch {
+	case strings.HasPrefix(t, "[]"):
+		return isKnownType(t[2:])
+	case strings.HasPrefix(t, "map["):
+		if idx := strings.Index(t, "]"); idx >= 0 {
+			return isKnownType(t[4:idx]) && isKnownType(t[idx+1:])
+		}
+		return false
+	default:
+		return knownTypes[t]
+	}
+}
+
+// warnUnknownTypes logs a warning, once per type, for each attribute whose
+// type is unknown and so is represented as a string.
+func warnUnknownTypes(ctx context.Context, resourceName string, attrs []attributeMeta) {
+	for _, a := range attrs {
+		if isKnownType(a.Type) {
+			continue
+		}
+		if _, warned := warnedTypes.LoadOrStore(a.Type, true); !warned {
+			logWarn(ctx, "Unknown kaiak attribute type: representing as a string", map[string]interface{}{
+				"type":      a.Type,
+				"resource":  resourceName,
+				"attribute": a.Name,
+			})
+		}
+	}
+}
+
 // kaiakTypeToAttrType returns the terraform attr.Type for a kaiak type string.
 func kaiakTypeToAttrType(t string) attr.Type {
 	switch {
@@ -173,7 +569,9 @@ func kaiakMapElemType(t string) attr.Type {
 }
 
 // kaiakValueToTF converts a kaiak state value to a terraform attr.Value.
-func kaiakValueToTF(ctx context.Context, v any, t string) attr.Value {
+// Nothing is logged about the values of sensitive attributes, not even
+// their type, as the schema marks the state value itself sensitive.
+func kaiakValueToTF(ctx context.Context, v any, t string, sensitive bool) attr.Value {
 	if v == nil {
 		return kaiakNullValue(t)
 	}
@@ -185,9 +583,21 @@ func kaiakValueToTF(ctx context.Context, v any, t string) attr.Value {
 	case t == "int" || t == "uint":
 		switch n := v.(type) {
 		case float64:
+			// Values outside the int64 range (or negative for uint) cannot be
+			// represented in terraform, so are never silently wrapped around
+			if n < math.MinInt64 || n >= math.MaxInt64 || (t == "uint" && n < 0) {
+				if !sensitive {
+					logError(ctx, "Kaiak attribute value out of range: setting to null", map[string]interface{}{
+						"declared_type": t,
+					})
+				}
+				return kaiakNullValue(t)
+			}
 			return types.Int64Value(int64(n))
 		case int:
 			return types.Int64Value(int64(n))
+		case int64:
+			return types.Int64Value(n)
 		}
 	case t == "float":
 		switch n := v.(type) {
@@ -195,43 +605,166 @@ func kaiakValueToTF(ctx context.Context, v any, t string) attr.Value {
 			return types.Float64Value(n)
 		case int:
 			return types.Float64Value(float64(n))
+		case int64:
+			return types.Float64Value(float64(n))
 		}
 	case strings.HasPrefix(t, "[]"):
-		return kaiakSliceToTF(ctx, v, t)
+		return kaiakSliceToTF(ctx, v, t, sensitive)
 	case strings.HasPrefix(t, "map["):
-		return kaiakMapToTF(ctx, v, t)
+		return kaiakMapToTF(ctx, v, t, sensitive)
 	case t == "time":
-		// The server marshals time.Time as RFC 3339 via JSON.
+		// The server marshals time.Time as RFC 3339 via JSON, but some
+		// servers emit Unix time in seconds or milliseconds instead
+		if parsed, ok := kaiakTime(v); ok {
+			return types.StringValue(formatTime(ctx, parsed))
+		}
 		if s, ok := v.(string); ok {
-			if parsed, err := time.Parse(time.RFC3339, s); err == nil {
-				return types.StringValue(parsed.Format(time.RFC3339))
-			}
 			return types.StringValue(s)
 		}
 	}
 
-	// Value does not match its declared type — fall back to string but
-	// log the mismatch so server-side data issues are not silently hidden.
-	// The raw value is intentionally omitted to avoid leaking sensitive data.
-	if t != "string" && t != "duration" && t != "ref" {
-		tflog.Warn(ctx, "Kaiak attribute type mismatch: coercing to string", map[string]interface{}{
+	// Value does not match its declared type, for example because the type
+	// changed in a server upgrade. Coerce it on a best-effort basis, or set
+	// it to null, so that the state always matches the schema. The mismatch
+	// is logged so server-side data issues are not silently hidden, but the
+	// raw value is intentionally omitted to avoid leaking sensitive data.
+	coerced, ok := kaiakCoerce(v, t)
+	if t != "string" && t != "duration" && t != "ref" && !sensitive {
+		msg := "Kaiak attribute type mismatch: coercing value"
+		if !ok {
+			msg = "Kaiak attribute type mismatch: setting to null"
+		}
+		logWarn(ctx, msg, map[string]interface{}{
 			"declared_type": t,
 			"actual_type":   fmt.Sprintf("%T", v),
 		})
 	}
-	return types.StringValue(fmt.Sprintf("%v", v))
+	return coerced
+}
+
+// kaiakCoerce converts a value which does not match its declared type,
+// parsing strings as numbers or booleans and representing anything else as
+// a string for string types. It returns a typed null and false when the value
+// cannot be converted.
+func kaiakCoerce(v any, t string) (attr.Value, bool) {
+	if kaiakTypeToAttrType(t).Equal(types.StringType) {
+		return types.StringValue(fmt.Sprintf("%v", v)), true
+	}
+	s, ok := v.(string)
+	if !ok {
+		return kaiakNullValue(t), false
+	}
+	s = strings.TrimSpace(s)
+	switch t {
+	case "bool":
+		if b, err := strconv.ParseBool(s); err == nil {
+			return types.BoolValue(b), true
+		}
+	case "int", "uint":
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil && (t == "int" || n >= 0) {
+			return types.Int64Value(n), true
+		}
+	case "float":
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			return types.Float64Value(n), true
+		}
+	}
+	return kaiakNullValue(t), false
+}
+
+// kaiakValueMatches returns true if a value from the server matches its
+// declared kaiak type, including the elements of a list or map. Any value
+// matches a string type, as it is represented by its string form.
+func kaiakValueMatches(v any, t string) bool {
+	switch {
+	case v == nil:
+		return true
+	case t == "bool":
+		_, ok := v.(bool)
+		return ok
+	case t == "int" || t == "uint" || t == "float":
+		switch v.(type) {
+		case float64, int, int64:
+			return true
+		}
+		return false
+	case strings.HasPrefix(t, "[]"):
+		items, ok := kaiakItems(v)
+		if !ok {
+			return false
+		}
+		for _, item := range items {
+			if !kaiakValueMatches(item, t[2:]) {
+				return false
+			}
+		}
+		return true
+	case strings.HasPrefix(t, "map["):
+		items, ok := v.(map[string]interface{})
+		idx := strings.Index(t, "]")
+		if !ok || idx < 0 || idx+1 >= len(t) {
+			return ok
+		}
+		for _, item := range items {
+			if !kaiakValueMatches(item, t[idx+1:]) {
+				return false
+			}
+		}
+		return true
+	}
+	return true
+}
+
+// kaiakTime parses a time value from the server, either an RFC 3339 string
+// or a number of seconds since the Unix epoch. Numbers too large to be
+// seconds (from year 33658) are taken to be milliseconds.
+func kaiakTime(v any) (time.Time, bool) {
+	var n float64
+	switch v := v.(type) {
+	case string:
+		parsed, err := time.Parse(time.RFC3339, v)
+		return parsed, err == nil
+	case float64:
+		n = v
+	case int:
+		n = float64(v)
+	case int64:
+		n = float64(v)
+	default:
+		return time.Time{}, false
+	}
+	if math.Abs(n) >= 1e12 {
+		return time.UnixMilli(int64(n)).UTC(), true
+	}
+	sec, frac := math.Modf(n)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC(), true
+}
+
+// withTimeFormat returns a context which selects the representation of time
+// values in state: timeFormatRFC3339 or timeFormatUnix.
+func withTimeFormat(ctx context.Context, format string) context.Context {
+	return context.WithValue(ctx, timeFormatKey{}, format)
+}
+
+// formatTime formats a time value for state, in the format selected by
+// the context, which is RFC 3339 by default.
+func formatTime(ctx context.Context, t time.Time) string {
+	if format, _ := ctx.Value(timeFormatKey{}).(string); format == timeFormatUnix {
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+	return t.Format(time.RFC3339)
 }
 
 // kaiakSliceToTF converts a kaiak slice value to a terraform ListValue.
-func kaiakSliceToTF(ctx context.Context, v any, t string) attr.Value {
+func kaiakSliceToTF(ctx context.Context, v any, t string, sensitive bool) attr.Value {
 	elemType := kaiakTypeToAttrType(t[2:])
-	items, ok := v.([]interface{})
+	items, ok := kaiakItems(v)
 	if !ok {
 		return types.ListNull(elemType)
 	}
-	elems := make([]attr.Value, 0, len(items))
-	for _, item := range items {
-		elems = append(elems, kaiakValueToTF(ctx, item, t[2:]))
+	elems := make([]attr.Value, len(items))
+	for i, item := range items {
+		elems[i] = kaiakValueToTF(ctx, item, t[2:], sensitive)
 	}
 	list, diags := types.ListValue(elemType, elems)
 	if diags.HasError() {
@@ -240,8 +773,36 @@ func kaiakSliceToTF(ctx context.Context, v any, t string) attr.Value {
 	return list
 }
 
+// kaiakItems returns the elements of a slice value, either a []interface{}
+// decoded from the server or a typed slice converted from the plan by
+// tfListToKaiak, and false for any other value.
+func kaiakItems(v any) ([]any, bool) {
+	switch v := v.(type) {
+	case []any:
+		return v, true
+	case []string:
+		return boxItems(v), true
+	case []int64:
+		return boxItems(v), true
+	case []float64:
+		return boxItems(v), true
+	case []bool:
+		return boxItems(v), true
+	}
+	return nil, false
+}
+
+// boxItems returns the elements of a typed slice as a []any.
+func boxItems[T any](v []T) []any {
+	items := make([]any, len(v))
+	for i, item := range v {
+		items[i] = item
+	}
+	return items
+}
+
 // kaiakMapToTF converts a kaiak map value to a terraform MapValue.
-func kaiakMapToTF(ctx context.Context, v any, t string) attr.Value {
+func kaiakMapToTF(ctx context.Context, v any, t string, sensitive bool) attr.Value {
 	elemType := kaiakMapElemType(t)
 	items, ok := v.(map[string]interface{})
 	if !ok {
@@ -249,7 +810,7 @@ func kaiakMapToTF(ctx context.Context, v any, t string) attr.Value {
 	}
 	idx := strings.Index(t, "]")
 	if idx < 0 || idx+1 >= len(t) {
-		tflog.Warn(ctx, "Malformed map type string, treating values as strings", map[string]interface{}{
+		logWarn(ctx, "Malformed map type string, treating values as strings", map[string]interface{}{
 			"type": t,
 		})
 		return types.MapNull(types.StringType)
@@ -257,7 +818,7 @@ func kaiakMapToTF(ctx context.Context, v any, t string) attr.Value {
 	valType := t[idx+1:]
 	elems := make(map[string]attr.Value, len(items))
 	for k, item := range items {
-		elems[k] = kaiakValueToTF(ctx, item, valType)
+		elems[k] = kaiakValueToTF(ctx, item, valType, sensitive)
 	}
 	m, diags := types.MapValue(elemType, elems)
 	if diags.HasError() {
@@ -287,75 +848,441 @@ func kaiakNullValue(t string) attr.Value {
 ///////////////////////////////////////////////////////////////////////////////
 // PRIVATE METHODS
 
+// apply returns a copy of the resource type with the settings applied: its
+// description replaced, attributes grouped into blocks and lists marked as
+// unique, and the separator and flags for building its schema set.
+func (s schemaSettings) apply(meta resourceMeta) resourceMeta {
+	if description, ok := s.descriptions[meta.Name]; ok {
+		meta.Description = description
+	}
+	meta.Separator, meta.StrictTypes, meta.BlockExtras = s.separator, s.strictTypes, s.blockExtras
+	meta.Attributes = slices.Clone(meta.Attributes)
+	for i := range meta.Attributes {
+		meta.Attributes[i].Separator = s.separator
+	}
+	meta.Attributes = groupAttributes(meta.Attributes, s.groups[meta.Name])
+	meta.Attributes = uniqueLists(meta.Attributes, s.unique[meta.Name])
+	return meta
+}
+
+// separator returns the separator of the blocks and field in the attribute
+// name.
+func (a attributeMeta) separator() string {
+	if a.Separator != "" {
+		return a.Separator
+	}
+	return defaultAttributeSeparator
+}
+
 // newAttrInfo derives terraform naming from a kaiak attribute.
-// Dots split into block + field (e.g. "tls.cert" → block "tls", field "cert").
-func newAttrInfo(a schema.Attribute) attrInfo {
+// The attribute separator splits into block + field (e.g. "tls.cert" →
+// block "tls", field "cert"), with a block nested for each further
+// separator (e.g. "tls.client.cert" → block "tls.client"), as does the prefix of a grouped attribute
+// (e.g. "log_level" → block "log", field "level").
+func newAttrInfo(a attributeMeta) attrInfo {
 	info := attrInfo{kaiakName: a.Name, attr: a}
-	if parts := strings.SplitN(a.Name, ".", 2); len(parts) == 2 {
-		info.tfBlock = parts[0]
-		info.tfField = strings.ReplaceAll(parts[1], ".", "_")
+	sep := a.separator()
+	if field, ok := strings.CutPrefix(a.Name, a.Group+groupSeparator); ok && a.Group != "" {
+		info.tfBlock = a.Group
+		info.tfField = field
+	} else if parts := strings.Split(a.Name, sep); len(parts) > 1 {
+		info.tfBlock = strings.Join(parts[:len(parts)-1], blockSeparator)
+		info.tfField = parts[len(parts)-1]
 	} else {
 		info.tfField = a.Name
 	}
 	return info
 }
 
+// groupAttributes returns a copy of the attributes with each top-level
+// attribute whose name starts with one of the prefixes and the group
+// separator (e.g. "log_level" for prefix "log") grouped into a block named
+// by the prefix. The longest matching prefix wins.
+func groupAttributes(attrs []attributeMeta, prefixes []string) []attributeMeta {
+	if len(prefixes) == 0 {
+		return attrs
+	}
+	grouped := slices.Clone(attrs)
+	for i, a := range grouped {
+		if strings.Contains(a.Name, a.separator()) {
+			continue
+		}
+		for _, prefix := range prefixes {
+			field, ok := strings.CutPrefix(a.Name, prefix+groupSeparator)
+			if ok && prefix != "" && field != "" && len(prefix) > len(grouped[i].Group) {
+				grouped[i].Group = prefix
+			}
+		}
+	}
+	return grouped
+}
+
+// uniqueLists returns a copy of the attributes with each named list
+// attribute marked as having unique elements. Other attributes are left
+// unchanged.
+func uniqueLists(attrs []attributeMeta, names []string) []attributeMeta {
+	if len(names) == 0 {
+		return attrs
+	}
+	marked := slices.Clone(attrs)
+	for i, a := range marked {
+		if strings.HasPrefix(a.Type, "[]") && slices.Contains(names, a.Name) {
+			marked[i].UniqueItems = true
+		}
+	}
+	return marked
+}
+
+// markdownDescription returns the Markdown description of an attribute: the
+// Markdown description from the server metadata, or else the plain
+// description when it contains Markdown, or else empty so that tools use
+// the plain description.
+func markdownDescription(a attributeMeta) string {
+	if a.MarkdownDescription != "" {
+		return a.MarkdownDescription
+	}
+	for _, syntax := range []string{"`", "**", "](", "\n- ", "\n* ", "\n#"} {
+		if strings.Contains(a.Description, syntax) {
+			return a.Description
+		}
+	}
+	return ""
+}
+
+// checkConstraints returns an error if the pattern or element counts in the
+// attribute metadata are invalid, the counts are set on an attribute which
+// is not a list or map, allowed values are set on an attribute which does
+// not hold strings, a list or map is marked as an address, or the replace
+// condition is unknown or does not apply to the type.
+func checkConstraints(a attributeMeta) error {
+	if a.ReplaceIf != "" {
+		if c, ok := replaceComparators[a.ReplaceIf]; !ok {
+			return fmt.Errorf("unknown replace_if condition %q", a.ReplaceIf)
+		} else if c.types != nil && !c.types(a.Type) {
+			return fmt.Errorf("replace_if condition %q does not apply to type %q", a.ReplaceIf, a.Type)
+		}
+	}
+	if a.Address && (strings.HasPrefix(a.Type, "[]") || strings.HasPrefix(a.Type, "map[")) {
+		return fmt.Errorf("address requires a scalar attribute, not %q", a.Type)
+	}
+	if len(a.Enum) > 0 && !stringValued(a.Type) {
+		return fmt.Errorf("enum requires a string, or a list or map of strings, not %q", a.Type)
+	}
+	if a.Pattern != "" {
+		if _, err := regexp.Compile(a.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	if a.UniqueItems && !strings.HasPrefix(a.Type, "[]") {
+		return fmt.Errorf("unique_items requires a list, not %q", a.Type)
+	}
+	if a.MinItems == nil && a.MaxItems == nil {
+		return nil
+	}
+	if !strings.HasPrefix(a.Type, "[]") && !strings.HasPrefix(a.Type, "map[") {
+		return fmt.Errorf("min_items and max_items require a list or map, not %q", a.Type)
+	}
+	if a.MinItems != nil && *a.MinItems < 0 {
+		return fmt.Errorf("min_items must not be negative")
+	}
+	if a.MinItems != nil && a.MaxItems != nil && *a.MinItems > *a.MaxItems {
+		return fmt.Errorf("min_items %d is greater than max_items %d", *a.MinItems, *a.MaxItems)
+	}
+	return nil
+}
+
+// isNumberType returns true if the kaiak type is represented as a number.
+func isNumberType(t string) bool {
+	return t == "int" || t == "uint" || t == "float"
+}
+
+// numberValue returns a known, non-null number value as a float64.
+func numberValue(v attr.Value) (float64, bool) {
+	if v.IsNull() || v.IsUnknown() {
+		return 0, false
+	}
+	switch v := v.(type) {
+	case types.Int64:
+		return float64(v.ValueInt64()), true
+	case types.Float64:
+		return v.ValueFloat64(), true
+	}
+	return 0, false
+}
+
+// removesElements returns true if the planned list or map lacks an element
+// of the prior one: a value of a list, or a key of a map. An unknown planned
+// value is not known to remove anything.
+func removesElements(prior, planned attr.Value) bool {
+	if prior.IsNull() || prior.IsUnknown() || planned.IsUnknown() {
+		return false
+	}
+	if planned.IsNull() {
+		return true
+	}
+	switch prior := prior.(type) {
+	case types.List:
+		elems := planned.(types.List).Elements()
+		for _, p := range prior.Elements() {
+			if !slices.ContainsFunc(elems, p.Equal) {
+				return true
+			}
+		}
+	case types.Map:
+		elems := planned.(types.Map).Elements()
+		for k := range prior.Elements() {
+			if _, ok := elems[k]; !ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// replaceCondition returns the comparator and description for the replace
+// condition of the attribute, or nil if it has none.
+func replaceCondition(a attributeMeta) (func(prior, planned attr.Value) bool, string) {
+	c, ok := replaceComparators[a.ReplaceIf]
+	if !ok {
+		return nil, ""
+	}
+	return c.compare, c.desc
+}
+
+// stringValued returns true if the kaiak type is represented as a string, or
+// a list or map of strings.
+func stringValued(t string) bool {
+	switch {
+	case strings.HasPrefix(t, "[]"):
+		return kaiakTypeToAttrType(t[2:]) == types.StringType
+	case strings.HasPrefix(t, "map["):
+		return kaiakMapElemType(t) == types.StringType
+	default:
+		return kaiakTypeToAttrType(t) == types.StringType
+	}
+}
+
+// enumValidator returns a validator for the allowed values of the attribute,
+// or nil if it has none.
+func enumValidator(a attributeMeta) validator.String {
+	if len(a.Enum) == 0 {
+		return nil
+	}
+	return stringvalidator.OneOf(a.Enum...)
+}
+
+// describeEnum appends the allowed values of the attribute, if any, to its
+// plain and Markdown descriptions.
+func describeEnum(a attributeMeta, desc, md string) (string, string) {
+	if len(a.Enum) == 0 {
+		return desc, md
+	}
+	quoted := make([]string, len(a.Enum))
+	for i, v := range a.Enum {
+		quoted[i] = "`" + v + "`"
+	}
+	if md == "" {
+		md = desc
+	}
+	return strings.TrimSpace(desc + " Allowed values: " + strings.Join(a.Enum, ", ") + "."),
+		strings.TrimSpace(md + " Allowed values: " + strings.Join(quoted, ", ") + ".")
+}
+
+// patternValidator returns a validator for the attribute pattern, or nil if
+// it has none or it is invalid.
+func patternValidator(a attributeMeta) validator.String {
+	if a.Pattern == "" {
+		return nil
+	}
+	re, err := regexp.Compile(a.Pattern)
+	if err != nil {
+		return nil
+	}
+	return stringvalidator.RegexMatches(re, fmt.Sprintf("must match the pattern %q", a.Pattern))
+}
+
 // kaiakAttrToTF converts a single kaiak attribute to a terraform schema attribute.
 // Optional attributes are marked Computed so the server can supply defaults
 // without Terraform flagging an inconsistent result after apply.
-func kaiakAttrToTF(a schema.Attribute) tfschema.Attribute {
+func kaiakAttrToTF(a attributeMeta) tfschema.Attribute {
 	opt := !a.Required && !a.ReadOnly
-	computed := a.ReadOnly || opt // server may fill in defaults for optional attrs
+	computed := (a.ReadOnly || opt) && !a.WriteOnly // server may fill in defaults for optional attrs
+	desc, md := describeEnum(a, a.Description, markdownDescription(a))
+	replace, replaceDesc := replaceCondition(a)
+	if a.WriteOnly {
+		replace = nil // never in state, so there is no prior value to compare
+	}
 	switch {
 	case a.Type == "bool":
+		var modifiers []planmodifier.Bool
+		if replace != nil {
+			modifiers = append(modifiers, boolplanmodifier.RequiresReplaceIf(func(_ context.Context, req planmodifier.BoolRequest, resp *boolplanmodifier.RequiresReplaceIfFuncResponse) {
+				resp.RequiresReplace = replace(req.StateValue, req.PlanValue)
+			}, replaceDesc, replaceDesc))
+		}
 		return tfschema.BoolAttribute{
-			Description: a.Description,
-			Required:    a.Required,
-			Optional:    opt,
-			Computed:    computed,
-			Sensitive:   a.Sensitive,
+			Description:         desc,
+			MarkdownDescription: md,
+			Required:            a.Required,
+			Optional:            opt,
+			Computed:            computed,
+			Sensitive:           a.Sensitive,
+			WriteOnly:           a.WriteOnly,
+			DeprecationMessage:  a.Deprecated,
+			PlanModifiers:       modifiers,
 		}
 	case a.Type == "int" || a.Type == "uint":
+		var validators []validator.Int64
+		if a.Type == "uint" {
+			validators = append(validators, int64validator.AtLeast(0))
+		}
+		var modifiers []planmodifier.Int64
+		if replace != nil {
+			modifiers = append(modifiers, int64planmodifier.RequiresReplaceIf(func(_ context.Context, req planmodifier.Int64Request, resp *int64planmodifier.RequiresReplaceIfFuncResponse) {
+				resp.RequiresReplace = replace(req.StateValue, req.PlanValue)
+			}, replaceDesc, replaceDesc))
+		}
 		return tfschema.Int64Attribute{
-			Description: a.Description,
-			Required:    a.Required,
-			Optional:    opt,
-			Computed:    computed,
-			Sensitive:   a.Sensitive,
+			Description:         desc,
+			MarkdownDescription: md,
+			Required:            a.Required,
+			Optional:            opt,
+			Computed:            computed,
+			Sensitive:           a.Sensitive,
+			WriteOnly:           a.WriteOnly,
+			DeprecationMessage:  a.Deprecated,
+			PlanModifiers:       modifiers,
+			Validators:          validators,
 		}
 	case a.Type == "float":
+		var modifiers []planmodifier.Float64
+		if replace != nil {
+			modifiers = append(modifiers, float64planmodifier.RequiresReplaceIf(func(_ context.Context, req planmodifier.Float64Request, resp *float64planmodifier.RequiresReplaceIfFuncResponse) {
+				resp.RequiresReplace = replace(req.StateValue, req.PlanValue)
+			}, replaceDesc, replaceDesc))
+		}
 		return tfschema.Float64Attribute{
-			Description: a.Description,
-			Required:    a.Required,
-			Optional:    opt,
-			Computed:    computed,
-			Sensitive:   a.Sensitive,
+			Description:         desc,
+			MarkdownDescription: md,
+			Required:            a.Required,
+			Optional:            opt,
+			Computed:            computed,
+			Sensitive:           a.Sensitive,
+			WriteOnly:           a.WriteOnly,
+			DeprecationMessage:  a.Deprecated,
+			PlanModifiers:       modifiers,
 		}
 	case strings.HasPrefix(a.Type, "[]"):
+		var validators []validator.List
+		switch a.Type {
+		case "[]ref":
+			validators = append(validators, refValidator{})
+		case "[]uint":
+			validators = append(validators, listvalidator.ValueInt64sAre(int64validator.AtLeast(0)))
+		}
+		if a.MinItems != nil {
+			validators = append(validators, listvalidator.SizeAtLeast(*a.MinItems))
+		}
+		if a.MaxItems != nil {
+			validators = append(validators, listvalidator.SizeAtMost(*a.MaxItems))
+		}
+		if a.UniqueItems {
+			validators = append(validators, listvalidator.UniqueValues())
+		}
+		if v := patternValidator(a); v != nil && kaiakTypeToAttrType(a.Type[2:]) == types.StringType {
+			validators = append(validators, listvalidator.ValueStringsAre(v))
+		}
+		if v := enumValidator(a); v != nil && kaiakTypeToAttrType(a.Type[2:]) == types.StringType {
+			validators = append(validators, listvalidator.ValueStringsAre(v))
+		}
+		var modifiers []planmodifier.List
+		if replace != nil {
+			modifiers = append(modifiers, listplanmodifier.RequiresReplaceIf(func(_ context.Context, req planmodifier.ListRequest, resp *listplanmodifier.RequiresReplaceIfFuncResponse) {
+				resp.RequiresReplace = replace(req.StateValue, req.PlanValue)
+			}, replaceDesc, replaceDesc))
+		}
 		return tfschema.ListAttribute{
-			Description: a.Description,
-			ElementType: kaiakTypeToAttrType(a.Type[2:]),
-			Required:    a.Required,
-			Optional:    opt,
-			Computed:    computed,
-			Sensitive:   a.Sensitive,
+			Description:         desc,
+			MarkdownDescription: md,
+			ElementType:         kaiakTypeToAttrType(a.Type[2:]),
+			Required:            a.Required,
+			Optional:            opt,
+			Computed:            computed,
+			Sensitive:           a.Sensitive,
+			WriteOnly:           a.WriteOnly,
+			DeprecationMessage:  a.Deprecated,
+			PlanModifiers:       modifiers,
+			Validators:          validators,
 		}
 	case strings.HasPrefix(a.Type, "map["):
+		var validators []validator.Map
+		if strings.HasSuffix(a.Type, "]uint") {
+			validators = append(validators, mapvalidator.ValueInt64sAre(int64validator.AtLeast(0)))
+		}
+		if a.MinItems != nil {
+			validators = append(validators, mapvalidator.SizeAtLeast(*a.MinItems))
+		}
+		if a.MaxItems != nil {
+			validators = append(validators, mapvalidator.SizeAtMost(*a.MaxItems))
+		}
+		if v := patternValidator(a); v != nil && kaiakMapElemType(a.Type) == types.StringType {
+			validators = append(validators, mapvalidator.ValueStringsAre(v))
+		}
+		if v := enumValidator(a); v != nil && kaiakMapElemType(a.Type) == types.StringType {
+			validators = append(validators, mapvalidator.ValueStringsAre(v))
+		}
+		var modifiers []planmodifier.Map
+		if replace != nil {
+			modifiers = append(modifiers, mapplanmodifier.RequiresReplaceIf(func(_ context.Context, req planmodifier.MapRequest, resp *mapplanmodifier.RequiresReplaceIfFuncResponse) {
+				resp.RequiresReplace = replace(req.StateValue, req.PlanValue)
+			}, replaceDesc, replaceDesc))
+		}
 		return tfschema.MapAttribute{
-			Description: a.Description,
-			ElementType: kaiakMapElemType(a.Type),
-			Required:    a.Required,
-			Optional:    opt,
-			Computed:    computed,
-			Sensitive:   a.Sensitive,
+			Description:         desc,
+			MarkdownDescription: md,
+			ElementType:         kaiakMapElemType(a.Type),
+			Required:            a.Required,
+			Optional:            opt,
+			Computed:            computed,
+			Sensitive:           a.Sensitive,
+			WriteOnly:           a.WriteOnly,
+			DeprecationMessage:  a.Deprecated,
+			PlanModifiers:       modifiers,
+			Validators:          validators,
 		}
 	default:
+		var validators []validator.String
+		if a.Type == "ref" {
+			validators = append(validators, refValidator{})
+		}
+		if v := patternValidator(a); v != nil {
+			validators = append(validators, v)
+		}
+		if v := enumValidator(a); v != nil {
+			validators = append(validators, v)
+		}
+		var customType basetypes.StringTypable
+		if names := attrNormalizers(a); len(names) > 0 {
+			customType = normalizedStringType{normalize: names}
+		}
+		var modifiers []planmodifier.String
+		if replace != nil {
+			modifiers = append(modifiers, stringplanmodifier.RequiresReplaceIf(func(_ context.Context, req planmodifier.StringRequest, resp *stringplanmodifier.RequiresReplaceIfFuncResponse) {
+				resp.RequiresReplace = replace(req.StateValue, req.PlanValue)
+			}, replaceDesc, replaceDesc))
+		}
 		return tfschema.StringAttribute{
-			Description: a.Description,
-			Required:    a.Required,
-			Optional:    opt,
-			Computed:    computed,
-			Sensitive:   a.Sensitive,
+			Description:         desc,
+			MarkdownDescription: md,
+			CustomType:          customType,
+			Required:            a.Required,
+			Optional:            opt,
+			Computed:            computed,
+			Sensitive:           a.Sensitive,
+			WriteOnly:           a.WriteOnly,
+			DeprecationMessage:  a.Deprecated,
+			PlanModifiers:       modifiers,
+			Validators:          validators,
 		}
 	}
 }
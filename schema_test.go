@@ -0,0 +1,371 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	// Packages
+	attr "github.com/hashicorp/terraform-plugin-framework/attr"
+	diag "github.com/hashicorp/terraform-plugin-framework/diag"
+	path "github.com/hashicorp/terraform-plugin-framework/path"
+	tfschema "github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	validator "github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	types "github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// HELPERS
+
+// validateAttr runs the validators of the schema attribute for a kaiak
+// attribute against a configured value, and returns their diagnostics.
+func validateAttr(t *testing.T, a attributeMeta, v attr.Value) diag.Diagnostics {
+	t.Helper()
+	ctx := context.Background()
+	p := path.Root(a.Name)
+	var diags diag.Diagnostics
+	switch tfAttr := kaiakAttrToTF(a).(type) {
+	case tfschema.StringAttribute:
+		for _, fn := range tfAttr.Validators {
+			var resp validator.StringResponse
+			fn.ValidateString(ctx, validator.StringRequest{Path: p, ConfigValue: v.(types.String)}, &resp)
+			diags.Append(resp.Diagnostics...)
+		}
+	case tfschema.Int64Attribute:
+		for _, fn := range tfAttr.Validators {
+			var resp validator.Int64Response
+			fn.ValidateInt64(ctx, validator.Int64Request{Path: p, ConfigValue: v.(types.Int64)}, &resp)
+			diags.Append(resp.Diagnostics...)
+		}
+	case tfschema.ListAttribute:
+		for _, fn := range tfAttr.Validators {
+			var resp validator.ListResponse
+			fn.ValidateList(ctx, validator.ListRequest{Path: p, ConfigValue: v.(types.List)}, &resp)
+			diags.Append(resp.Diagnostics...)
+		}
+	case tfschema.MapAttribute:
+		for _, fn := range tfAttr.Validators {
+			var resp validator.MapResponse
+			fn.ValidateMap(ctx, validator.MapRequest{Path: p, ConfigValue: v.(types.Map)}, &resp)
+			diags.Append(resp.Diagnostics...)
+		}
+	default:
+		t.Fatalf("no validators for %T", tfAttr)
+	}
+	return diags
+}
+
+// stringList returns a terraform list of strings.
+func stringList(values ...string) types.List {
+	elems := make([]attr.Value, len(values))
+	for i, v := range values {
+		elems[i] = types.StringValue(v)
+	}
+	return types.ListValueMust(types.StringType, elems)
+}
+
+// stringMap returns a terraform map of strings, from alternating keys and
+// values.
+func stringMap(pairs ...string) types.Map {
+	elems := make(map[string]attr.Value, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		elems[pairs[i]] = types.StringValue(pairs[i+1])
+	}
+	return types.MapValueMust(types.StringType, elems)
+}
+
+func TestBuildResourceSchemaNested(t *testing.T) {
+	s, infos, diags := buildResourceSchema(resourceMeta{Name: "x", Attributes: []attributeMeta{
+		attribute("tls.mode", "string"),
+		attribute("tls.client.cert", "string"),
+		attribute("tls.client.ca.file", "string"),
+	}})
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+
+	tls, ok := s.Attributes["tls"].(tfschema.SingleNestedAttribute)
+	if !ok {
+		t.Fatalf("tls is %T, want a block", s.Attributes["tls"])
+	}
+	client, ok := tls.Attributes["client"].(tfschema.SingleNestedAttribute)
+	if !ok {
+		t.Fatalf("tls.client is %T, want a block", tls.Attributes["client"])
+	}
+	ca, ok := client.Attributes["ca"].(tfschema.SingleNestedAttribute)
+	if !ok {
+		t.Fatalf("tls.client.ca is %T, want a block", client.Attributes["ca"])
+	}
+	for name, attrs := range map[string]map[string]tfschema.Attribute{
+		"tls.mode":           tls.Attributes,
+		"tls.client.cert":    client.Attributes,
+		"tls.client.ca.file": ca.Attributes,
+	} {
+		field := name[len(name)-4:]
+		if _, ok := attrs[field].(tfschema.StringAttribute); !ok {
+			t.Errorf("%s is %T, want a string", name, attrs[field])
+		}
+	}
+
+	paths := map[string]string{}
+	for _, info := range infos {
+		paths[info.kaiakName] = info.path().String()
+	}
+	if got := paths["tls.client.ca.file"]; got != "tls.client.ca.file" {
+		t.Errorf("path %q, want %q", got, "tls.client.ca.file")
+	}
+}
+
+func TestBuildResourceSchemaNestedCollision(t *testing.T) {
+	// A block cannot have the same name as an attribute of its parent
+	_, _, diags := buildResourceSchema(resourceMeta{Name: "x", Attributes: []attributeMeta{
+		attribute("tls.client", "string"),
+		attribute("tls.client.cert", "string"),
+	}})
+	if !diags.HasError() {
+		t.Error("expected a naming collision")
+	}
+}
+
+func TestBuildResourceSchemaEmpty(t *testing.T) {
+	// A resource type without attributes has only the fixed attributes
+	s, infos, diags := buildResourceSchema(resourceMeta{Name: "x"})
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	if infos == nil || len(infos) != 0 {
+		t.Errorf("infos %v, want empty and non-nil", infos)
+	}
+	for name, a := range s.Attributes {
+		if _, ok := a.(tfschema.SingleNestedAttribute); ok {
+			t.Errorf("unexpected block %q", name)
+		}
+	}
+	if _, ok := s.Attributes["id"]; !ok {
+		t.Error("missing id attribute")
+	}
+
+	// A block with only read-only members cannot be configured
+	phase := attribute("status.phase", "string")
+	phase.ReadOnly = true
+	s, _, diags = buildResourceSchema(resourceMeta{Name: "x", Attributes: []attributeMeta{phase}})
+	if diags.HasError() {
+		t.Fatal(diags)
+	}
+	status, ok := s.Attributes["status"].(tfschema.SingleNestedAttribute)
+	if !ok {
+		t.Fatalf("status is %T, want a block", s.Attributes["status"])
+	}
+	if status.IsRequired() || status.IsOptional() || !status.IsComputed() {
+		t.Errorf("status block is configurable")
+	}
+}
+
+func TestNestedCollections(t *testing.T) {
+	tests := []struct {
+		typ   string
+		value string // as JSON from the server
+	}{
+		{"[]map[string]string", `[{"a":"1","b":"2"},{},{"c":"3"}]`},
+		{"map[string][]int", `{"a":[1,2,3],"b":[]}`},
+		{"[]map[string][]int", `[{"a":[1,2]},{"b":[3]}]`},
+		{"map[string][]map[string]string", `{"a":[{"x":"1"}],"b":[{"y":"2"},{"z":"3"}]}`},
+		{"[][]bool", `[[true,false],[],[true]]`},
+		{"map[string]map[string]float", `{"a":{"x":1.5},"b":{}}`},
+	}
+	ctx := context.Background()
+	for _, test := range tests {
+		t.Run(test.typ, func(t *testing.T) {
+			var v any
+			if err := json.Unmarshal([]byte(test.value), &v); err != nil {
+				t.Fatal(err)
+			}
+
+			// The value converts to terraform with the declared type
+			tf := kaiakValueToTF(ctx, v, test.typ, false)
+			if tf.IsNull() {
+				t.Fatal("converted to null")
+			}
+			if want := kaiakTypeToAttrType(test.typ); !tf.Type(ctx).Equal(want) {
+				t.Fatalf("type %s, want %s", tf.Type(ctx), want)
+			}
+
+			// and back to the same value
+			back, err := tfElemToGo(tf, test.typ)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data, err := json.Marshal(back); err != nil {
+				t.Fatal(err)
+			} else if string(data) != test.value {
+				t.Errorf("round trip %s, want %s", data, test.value)
+			}
+		})
+	}
+}
+
+func TestNestedCollectionMismatch(t *testing.T) {
+	// An element of the wrong type is an error, not coerced to a string
+	elem := types.MapValueMust(types.StringType, map[string]attr.Value{"a": types.StringValue("1")})
+	list := types.ListValueMust(elem.Type(context.Background()), []attr.Value{elem})
+	if _, err := tfElemToGo(list, "[][]string"); err == nil {
+		t.Error("expected an error for a list of maps declared as a list of lists")
+	}
+}
+
+func TestCollectionValidators(t *testing.T) {
+	two, three := 2, 3
+	sized := attribute("hosts", "[]string")
+	sized.MinItems, sized.MaxItems = &two, &three
+	sizedMap := attribute("labels", "map[string]string")
+	sizedMap.MinItems, sizedMap.MaxItems = &two, &three
+	unique := attribute("hosts", "[]string")
+	unique.UniqueItems = true
+	pattern := attribute("name", "string")
+	pattern.Pattern = "^[a-z]+$"
+	listPattern := attribute("names", "[]string")
+	listPattern.Pattern = "^[a-z]+$"
+	mapPattern := attribute("labels", "map[string]string")
+	mapPattern.Pattern = "^[a-z]+$"
+	intPattern := attribute("ports", "[]int")
+	intPattern.Pattern = "^[a-z]+$"
+
+	tests := []struct {
+		name  string
+		attr  attributeMeta
+		value attr.Value
+		valid bool
+	}{
+		{"min_items below", sized, stringList("a"), false},
+		{"min_items", sized, stringList("a", "b"), true},
+		{"max_items", sized, stringList("a", "b", "c"), true},
+		{"max_items above", sized, stringList("a", "b", "c", "d"), false},
+		{"map min_items below", sizedMap, stringMap("a", "1"), false},
+		{"map max_items above", sizedMap, stringMap("a", "1", "b", "2", "c", "3", "d", "4"), false},
+		{"map size", sizedMap, stringMap("a", "1", "b", "2"), true},
+		{"unique_items", unique, stringList("a", "b"), true},
+		{"unique_items duplicate", unique, stringList("a", "b", "a"), false},
+		{"pattern", pattern, types.StringValue("abc"), true},
+		{"pattern mismatch", pattern, types.StringValue("ABC"), false},
+		{"list pattern", listPattern, stringList("abc", "def"), true},
+		{"list pattern mismatch", listPattern, stringList("abc", "D"), false},
+		{"map pattern mismatch", mapPattern, stringMap("a", "1"), false},
+		{"map pattern", mapPattern, stringMap("a", "x"), true},
+		{"pattern ignored for numbers", intPattern, types.ListValueMust(types.Int64Type, []attr.Value{types.Int64Value(1)}), true},
+		{"null", sized, types.ListNull(types.StringType), true},
+		{"unknown", unique, types.ListUnknown(types.StringType), true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diags := validateAttr(t, test.attr, test.value); diags.HasError() == test.valid {
+				t.Errorf("valid %v, want %v: %v", !diags.HasError(), test.valid, diags)
+			}
+		})
+	}
+}
+
+func TestEnumValidator(t *testing.T) {
+	if v := enumValidator(attribute("mode", "string")); v != nil {
+		t.Errorf("validator %v for an attribute without allowed values", v)
+	}
+
+	enum := func(name, typ string) attributeMeta {
+		a := attribute(name, typ)
+		a.Enum = []string{"tcp", "udp"}
+		return a
+	}
+	tests := []struct {
+		name  string
+		attr  attributeMeta
+		value attr.Value
+		valid bool
+	}{
+		{"string", enum("mode", "string"), types.StringValue("tcp"), true},
+		{"string not allowed", enum("mode", "string"), types.StringValue("icmp"), false},
+		{"string case", enum("mode", "string"), types.StringValue("TCP"), false},
+		{"string null", enum("mode", "string"), types.StringNull(), true},
+		{"list", enum("modes", "[]string"), stringList("tcp", "udp"), true},
+		{"list not allowed", enum("modes", "[]string"), stringList("tcp", "icmp"), false},
+		{"list empty", enum("modes", "[]string"), stringList(), true},
+		{"map", enum("modes", "map[string]string"), stringMap("a", "udp"), true},
+		{"map not allowed", enum("modes", "map[string]string"), stringMap("a", "udp", "b", "icmp"), false},
+		{"ignored for numbers", enum("ports", "map[string]int"), types.MapValueMust(types.Int64Type, map[string]attr.Value{"a": types.Int64Value(1)}), true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if diags := validateAttr(t, test.attr, test.value); diags.HasError() == test.valid {
+				t.Errorf("valid %v, want %v: %v", !diags.HasError(), test.valid, diags)
+			}
+		})
+	}
+
+	// The allowed values are described
+	desc, md := describeEnum(enum("mode", "string"), "Protocol.", "")
+	if desc != "Protocol. Allowed values: tcp, udp." || md != "Protocol. Allowed values: `tcp`, `udp`." {
+		t.Errorf("descriptions %q and %q", desc, md)
+	}
+}
+
+func TestReplaceComparators(t *testing.T) {
+	ints := func(values ...int64) types.List {
+		elems := make([]attr.Value, len(values))
+		for i, v := range values {
+			elems[i] = types.Int64Value(v)
+		}
+		return types.ListValueMust(types.Int64Type, elems)
+	}
+	tests := []struct {
+		name    string
+		cmp     string
+		prior   attr.Value
+		planned attr.Value
+		replace bool
+	}{
+		{"always", "always", types.StringValue("a"), types.StringValue("b"), true},
+		{"always unknown", "always", types.StringValue("a"), types.StringUnknown(), true},
+		{"decrease", "decrease", types.Int64Value(3), types.Int64Value(2), true},
+		{"decrease increased", "decrease", types.Int64Value(3), types.Int64Value(4), false},
+		{"decrease float", "decrease", types.Float64Value(1.5), types.Float64Value(1.25), true},
+		{"decrease null", "decrease", types.Int64Value(3), types.Int64Null(), false},
+		{"decrease unknown", "decrease", types.Int64Value(3), types.Int64Unknown(), false},
+		{"decrease from null", "decrease", types.Int64Null(), types.Int64Value(1), false},
+		{"increase", "increase", types.Int64Value(3), types.Int64Value(4), true},
+		{"increase decreased", "increase", types.Int64Value(3), types.Int64Value(2), false},
+		{"increase null", "increase", types.Int64Value(3), types.Int64Null(), false},
+		{"increase unknown", "increase", types.Int64Value(3), types.Int64Unknown(), false},
+		{"remove list element", "remove", stringList("a", "b"), stringList("a"), true},
+		{"remove list added", "remove", stringList("a"), stringList("b", "a"), false},
+		{"remove list reordered", "remove", ints(1, 2), ints(2, 1), false},
+		{"remove list replaced", "remove", ints(1, 2), ints(1, 3), true},
+		{"remove map key", "remove", stringMap("a", "1", "b", "2"), stringMap("a", "1"), true},
+		{"remove map value changed", "remove", stringMap("a", "1"), stringMap("a", "2"), false},
+		{"remove null", "remove", stringList("a"), types.ListNull(types.StringType), true},
+		{"remove unknown", "remove", stringList("a"), types.ListUnknown(types.StringType), false},
+		{"remove from null", "remove", types.ListNull(types.StringType), stringList("a"), false},
+		{"remove from unknown", "remove", types.MapUnknown(types.StringType), stringMap("a", "1"), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := replaceComparators[test.cmp].compare(test.prior, test.planned); got != test.replace {
+				t.Errorf("replace %v, want %v", got, test.replace)
+			}
+		})
+	}
+}
+
+func TestReplaceComparatorTypes(t *testing.T) {
+	for cmp, types := range map[string]map[string]bool{
+		"decrease": {"int": true, "uint": true, "float": true, "string": false, "[]int": false},
+		"increase": {"int": true, "float": true, "bool": false},
+		"remove":   {"[]string": true, "map[string]int": true, "string": false, "int": false},
+	} {
+		for typ, want := range types {
+			if got := replaceComparators[cmp].types(typ); got != want {
+				t.Errorf("%s applies to %q: %v, want %v", cmp, typ, got, want)
+			}
+		}
+	}
+	if replaceComparators["always"].types != nil {
+		t.Error("always should apply to any type")
+	}
+}
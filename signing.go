@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	// Packages
+	client "github.com/mutablelogic/go-client"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// requestSigner holds the key and algorithm used to sign every request.
+type requestSigner struct {
+	key       []byte
+	algorithm string
+}
+
+// signingTransport signs each request before passing it to the next
+// transport.
+type signingTransport struct {
+	signer *requestSigner
+	base   http.RoundTripper
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+const (
+	signingHMACSHA256 = "hmac-sha256"
+	signingHMACSHA512 = "hmac-sha512"
+
+	signatureHeader = "X-Kaiak-Signature"
+	timestampHeader = "X-Kaiak-Timestamp"
+)
+
+// signingNow returns the time used for the timestamp header. It can be
+// replaced to sign requests with a fixed time.
+var signingNow = time.Now
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// newRequestSigner returns a signer for the given key and algorithm, which
+// defaults to hmac-sha256, or nil if the key is empty.
+func newRequestSigner(key, algorithm string) (*requestSigner, error) {
+	if key == "" {
+		return nil, nil
+	}
+	if algorithm == "" {
+		algorithm = signingHMACSHA256
+	}
+	if algorithm != signingHMACSHA256 && algorithm != signingHMACSHA512 {
+		return nil, fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+	return &requestSigner{key: []byte(key), algorithm: algorithm}, nil
+}
+
+// resolveSigner returns the request signer from the KAIAK_SIGNING_KEY and
+// KAIAK_SIGNING_ALGORITHM environment variables, or nil if no key is set.
+func resolveSigner() (*requestSigner, error) {
+	return newRequestSigner(os.Getenv("KAIAK_SIGNING_KEY"), os.Getenv("KAIAK_SIGNING_ALGORITHM"))
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// RoundTrip adds the timestamp and signature headers to a copy of the
+// request, leaving the original unmodified as RoundTripper requires.
+func (t *signingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = data
+	}
+
+	signed := req.Clone(req.Context())
+	if body != nil {
+		signed.Body = io.NopCloser(bytes.NewReader(body))
+		signed.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+	timestamp := strconv.FormatInt(signingNow().Unix(), 10)
+	signed.Header.Set(timestampHeader, timestamp)
+	signed.Header.Set(signatureHeader, t.signer.sign(req.Method, req.URL.EscapedPath(), body, timestamp))
+	return t.base.RoundTrip(signed)
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// sign returns the hex-encoded HMAC of the method, path, body and timestamp,
+// each followed by a newline.
+func (s *requestSigner) sign(method, path string, body []byte, timestamp string) string {
+	mac := hmac.New(s.hash(), s.key)
+	fmt.Fprintf(mac, "%s\n%s\n", method, path)
+	mac.Write(body)
+	fmt.Fprintf(mac, "\n%s\n", timestamp)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// hash returns the hash function for the signing algorithm.
+func (s *requestSigner) hash() func() hash.Hash {
+	if s.algorithm == signingHMACSHA512 {
+		return sha512.New
+	}
+	return sha256.New
+}
+
+// optSigner signs every request with the given signer.
+func optSigner(signer *requestSigner) client.ClientOpt {
+	return func(c *client.Client) error {
+		c.Transport = &signingTransport{signer: signer, base: c.Transport}
+		return nil
+	}
+}
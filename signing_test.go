@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	// Packages
+	tftypes "github.com/hashicorp/terraform-plugin-go/tftypes"
+	schema "github.com/mutablelogic/go-server/pkg/provider/schema"
+)
+
+func TestSigningCompressedBody(t *testing.T) {
+	signer, err := newRequestSigner("secret", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The server checks the signature against the body as received
+	var mu sync.Mutex
+	var encodings []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		want := signer.sign(req.Method, req.URL.EscapedPath(), body, req.Header.Get(timestampHeader))
+		if got := req.Header.Get(signatureHeader); got != want {
+			t.Errorf("signature %q does not match the body sent (%q)", got, want)
+		}
+		mu.Lock()
+		encodings = append(encodings, req.Header.Get("Content-Encoding"))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"instance":{"name":"x.a"}}`))
+	}))
+	defer srv.Close()
+
+	_, data := configure(t, map[string]tftypes.Value{
+		"endpoint":          stringValue(srv.URL),
+		"signing_key":       stringValue("secret"),
+		"compress_requests": tftypes.NewValue(tftypes.Bool, true),
+	})
+	ctx := context.Background()
+	if err := createResourceInstance(ctx, data.client, "x.a", schema.State{"value": strings.Repeat("x", 4*compressMinSize)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := createResourceInstance(ctx, data.client, "x.b", nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"gzip", ""}; !slices.Equal(encodings, want) {
+		t.Errorf("content encodings %q, want %q", encodings, want)
+	}
+}
+
+func TestSigningRetries(t *testing.T) {
+	signer, err := newRequestSigner("secret", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1700000000, 0)
+	t.Cleanup(func() { signingNow = time.Now })
+	signingNow = func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	}
+
+	// The server fails the first attempt, and checks each is signed afresh
+	var mu sync.Mutex
+	var timestamps []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		timestamp := req.Header.Get(timestampHeader)
+		if got, want := req.Header.Get(signatureHeader), signer.sign(req.Method, req.URL.EscapedPath(), body, timestamp); got != want {
+			t.Errorf("signature %q, want %q", got, want)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if timestamps = append(timestamps, timestamp); len(timestamps) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"instance":{"name":"x.a"}}`))
+	}))
+	defer srv.Close()
+
+	_, data := configure(t, map[string]tftypes.Value{
+		"endpoint":    stringValue(srv.URL),
+		"signing_key": stringValue("secret"),
+		"max_retries": tftypes.NewValue(tftypes.Number, 1),
+	})
+	if err := createResourceInstance(context.Background(), data.client, "x.a", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(timestamps) != 2 || timestamps[0] == timestamps[1] {
+		t.Errorf("timestamps %q, want two attempts signed at different times", timestamps)
+	}
+}
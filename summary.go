@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	// Packages
+	diag "github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// applySummary accumulates the instances created, updated and deleted
+// across a provider run, and writes them as JSON to a file for pipelines
+// which need a structured record of an apply.
+type applySummary struct {
+	sync.Mutex
+	path       string
+	operations []summaryOperation
+}
+
+// summaryFile is the JSON written to the summary file.
+type summaryFile struct {
+	Operations []summaryOperation `json:"operations"`
+	Counts     map[string]int     `json:"counts"` // operation → number which succeeded
+	Failed     int                `json:"failed"`
+}
+
+// summaryOperation records one create, update or delete of an instance.
+type summaryOperation struct {
+	Operation    string    `json:"operation"`
+	ResourceType string    `json:"resource_type"`
+	ID           string    `json:"id"`
+	Started      time.Time `json:"started"`
+	DurationMs   int64     `json:"duration_ms"`
+	Error        string    `json:"error,omitempty"`
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// LIFECYCLE
+
+// newApplySummary returns a summary written to the file named by the
+// KAIAK_SUMMARY_FILE environment variable, or nil when it is not set.
+func newApplySummary() *applySummary {
+	path := os.Getenv("KAIAK_SUMMARY_FILE")
+	if path == "" {
+		return nil
+	}
+	return &applySummary{path: path}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// observe starts timing an operation on the instance with the given id, and
+// returns a function which records it, failed if diags then has an error.
+// The file is rewritten with each recording, so that it covers the whole run
+// however the provider exits. It is safe to call on a nil receiver, in which
+// case nothing is recorded.
+func (s *applySummary) observe(ctx context.Context, resourceType, op, id string, diags *diag.Diagnostics) func() {
+	if s == nil {
+		return func() {}
+	}
+	start := time.Now()
+	return func() {
+		operation := summaryOperation{
+			Operation:    op,
+			ResourceType: resourceType,
+			ID:           id,
+			Started:      start.UTC(),
+			DurationMs:   time.Since(start).Milliseconds(),
+		}
+		if errs := diags.Errors(); len(errs) > 0 {
+			operation.Error = errs[0].Summary() + ": " + errs[0].Detail()
+		}
+		if err := s.record(operation); err != nil {
+			logWarn(ctx, "Failed to write the apply summary", map[string]interface{}{
+				"path":  s.path,
+				"error": err.Error(),
+			})
+		}
+	}
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// record adds an operation and rewrites the summary file, replacing it
+// atomically so that a reader never sees a partial file.
+func (s *applySummary) record(operation summaryOperation) error {
+	s.Lock()
+	defer s.Unlock()
+	s.operations = append(s.operations, operation)
+
+	summary := summaryFile{Operations: s.operations, Counts: map[string]int{}}
+	for _, op := range s.operations {
+		if op.Error != "" {
+			summary.Failed++
+		} else {
+			summary.Counts[op.Operation]++
+		}
+	}
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	// Packages
+	attr "github.com/hashicorp/terraform-plugin-framework/attr"
+	diag "github.com/hashicorp/terraform-plugin-framework/diag"
+	tfsdk "github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	types "github.com/hashicorp/terraform-plugin-framework/types"
+	schema "github.com/mutablelogic/go-server/pkg/provider/schema"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// valueTransform converts a string value on its way to the server, and
+// back again on read when the conversion can be reversed.
+type valueTransform struct {
+	send func(string) (string, error)
+	read func(string) (string, error) // nil when the conversion cannot be reversed
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// GLOBALS
+
+// transforms are applied to string attribute values sent to the server, by
+// name.
+var transforms = map[string]valueTransform{
+	"base64encode": {send: base64Encode, read: base64Decode},
+	"base64decode": {send: base64Decode, read: base64Encode},
+	"trimspace":    {send: lift(strings.TrimSpace)},
+	"tolower":      {send: lift(strings.ToLower)},
+	"toupper":      {send: lift(strings.ToUpper)},
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PUBLIC METHODS
+
+// checkTransforms returns an error naming the first unknown transform.
+func checkTransforms(names []string) error {
+	for _, name := range names {
+		if _, ok := transforms[name]; !ok {
+			return fmt.Errorf("unknown transform %q: expected one of %s", name, strings.Join(slices.Sorted(maps.Keys(transforms)), ", "))
+		}
+	}
+	return nil
+}
+
+///////////////////////////////////////////////////////////////////////////////
+// PRIVATE METHODS
+
+// attrTransforms returns the transforms for a writable string attribute:
+// those named in the transform_attributes provider setting, or otherwise in
+// the server metadata. Unknown transform names are ignored.
+func (r *dynamicResource) attrTransforms(info attrInfo) []string {
+	if info.attr.ReadOnly || info.attr.Type != "string" {
+		return nil
+	}
+	names, ok := r.transforms[info.kaiakName]
+	if !ok {
+		names = info.attr.Transform
+	}
+	return slices.DeleteFunc(slices.Clone(names), func(name string) bool {
+		_, ok := transforms[name]
+		return !ok
+	})
+}
+
+// transformAttrs applies the transforms of each attribute to the values
+// extracted from the plan, in order, so they are sent to the server in the
+// form it expects. A value which cannot be transformed, such as invalid
+// base64, is reported as an error against the attribute.
+func (r *dynamicResource) transformAttrs(attrs schema.State, diags *diag.Diagnostics) {
+	for _, info := range r.getInfos() {
+		names := r.attrTransforms(info)
+		v, ok := attrs[info.kaiakName].(string)
+		if len(names) == 0 || !ok || info.alias {
+			continue
+		}
+		for _, name := range names {
+			var err error
+			if v, err = transforms[name].send(v); err != nil {
+				diags.AddAttributeError(info.path(), "Invalid attribute value",
+					fmt.Sprintf("The value of %q cannot be transformed with %s: %s", info.kaiakName, name, err))
+				break
+			}
+		}
+		attrs[info.kaiakName] = v
+	}
+}
+
+// untransformState reverses the transforms of each attribute on the values
+// read from the server, in reverse order, so that state holds the form set
+// in configuration. A transform which cannot be reversed, or a value which
+// does not reverse, leaves the server value as it is.
+func (r *dynamicResource) untransformState(ctx context.Context, state schema.State) {
+	for _, info := range r.getInfos() {
+		names := r.attrTransforms(info)
+		v, ok := state[info.kaiakName].(string)
+		if len(names) == 0 || !ok || info.alias {
+			continue
+		}
+		for _, name := range slices.Backward(names) {
+			read := transforms[name].read
+			if read == nil {
+				break
+			}
+			s, err := read(v)
+			if err != nil {
+				logWarn(ctx, "Cannot reverse the transform of an attribute read from the server", map[string]interface{}{
+					"resource":  r.meta.Name,
+					"attribute": info.kaiakName,
+					"transform": name,
+					"error":     err.Error(),
+				})
+				break
+			}
+			v = s
+		}
+		state[info.kaiakName] = v
+	}
+}
+
+// transformedStrings keeps each transformed attribute as it is in ref (the
+// plan, or the prior state on refresh) when the value in state transforms
+// to the same value, so that a transform which cannot be reversed, such as
+// trimspace, does not cause a perpetual diff.
+func (r *dynamicResource) transformedStrings(ctx context.Context, ref attrGetter, tfState *tfsdk.State, diags *diag.Diagnostics) {
+	for _, info := range r.getInfos() {
+		names := r.attrTransforms(info)
+		if len(names) == 0 || info.alias {
+			continue
+		}
+		if info.tfBlock != "" {
+			var block types.Object
+			diags.Append(tfState.GetAttribute(ctx, blockPath(info.tfBlock), &block)...)
+			if block.IsNull() || block.IsUnknown() {
+				continue
+			}
+		}
+		var want, got attr.Value
+		diags.Append(ref.GetAttribute(ctx, info.path(), &want)...)
+		diags.Append(tfState.GetAttribute(ctx, info.path(), &got)...)
+		w, ok1 := toStringValue(ctx, want)
+		g, ok2 := toStringValue(ctx, got)
+		if !ok1 || !ok2 || w.IsNull() || w.IsUnknown() || g.IsNull() || g.IsUnknown() || w.Equal(g) {
+			continue
+		}
+		if sendAll(names, w.ValueString()) == sendAll(names, g.ValueString()) {
+			diags.Append(tfState.SetAttribute(ctx, info.path(), want)...)
+		}
+	}
+}
+
+// sendAll applies the named transforms to a value in order, returning the
+// value unchanged if any fails.
+func sendAll(names []string, v string) string {
+	s := v
+	for _, name := range names {
+		var err error
+		if s, err = transforms[name].send(s); err != nil {
+			return v
+		}
+	}
+	return s
+}
+
+// lift returns a transform which cannot fail.
+func lift(fn func(string) string) func(string) (string, error) {
+	return func(s string) (string, error) {
+		return fn(s), nil
+	}
+}
+
+func base64Encode(s string) (string, error) {
+	return base64.StdEncoding.EncodeToString([]byte(s)), nil
+}
+
+func base64Decode(s string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
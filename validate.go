@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+
+	// Packages
+	validator "github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	types "github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+///////////////////////////////////////////////////////////////////////////////
+// TYPES
+
+// refValidator checks that a reference attribute (or each element of a list
+// of references) is a fully qualified instance name "resource_type.label".
+type refValidator struct{}
+
+var _ validator.String = refValidator{}
+var _ validator.List = refValidator{}
+
+///////////////////////////////////////////////////////////////////////////////
+// VALIDATOR INTERFACE
+
+func (refValidator) Description(_ context.Context) string {
+	return "value must be an instance name of the form \"resource_type.label\""
+}
+
+func (v refValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (refValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if _, _, err := parseInstanceName(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid reference", err.Error())
+	}
+}
+
+func (refValidator) ValidateList(_ context.Context, req validator.ListRequest, resp *validator.ListResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	for i, e := range req.ConfigValue.Elements() {
+		s, ok := e.(types.String)
+		if !ok || s.IsNull() || s.IsUnknown() {
+			continue
+		}
+		if _, _, err := parseInstanceName(s.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(req.Path.AtListIndex(i), "Invalid reference", err.Error())
+		}
+	}
+}